@@ -0,0 +1,189 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+var errUsage = errors.New("usage")
+
+// stringList collects a repeatable string flag.
+type stringList []string
+
+func (s *stringList) String() string     { return strings.Join(*s, ",") }
+func (s *stringList) Set(v string) error { *s = append(*s, v); return nil }
+
+// parseArgs parses flags that may appear anywhere among the positional
+// arguments, so "dml get config.dml port --json" works as expected. Negative
+// numbers such as -5 are positional, and everything after "--" is too.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	fs.SetOutput(io.Discard)
+	var positional []string
+	for len(args) > 0 {
+		if isNegativeNumber(args[0]) {
+			positional = append(positional, args[0])
+			args = args[1:]
+			continue
+		}
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		if len(rest) == 0 {
+			break
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+	return positional, nil
+}
+
+// isNegativeNumber reports whether arg starts like a negative number, such as
+// -5, -1.5 or -30s. No flag name starts with a digit.
+func isNegativeNumber(arg string) bool {
+	return len(arg) > 1 && arg[0] == '-' && (arg[1] >= '0' && arg[1] <= '9' || arg[1] == '.')
+}
+
+func usageError(usage string, err error) int {
+	if err != nil && !errors.Is(err, flag.ErrHelp) && !errors.Is(err, errUsage) {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	}
+	fmt.Fprintf(os.Stderr, "Usage: %s\n", strings.TrimPrefix(usage, "Usage: "))
+	return exitUsage
+}
+
+// expandFiles resolves glob patterns. "-" stands for stdin and is kept as is.
+func expandFiles(args []string) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		if arg == "-" || !strings.ContainsAny(arg, "*?[") {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(arg)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no files match %s", arg)
+		}
+		sort.Strings(matches)
+		files = append(files, matches...)
+	}
+	return files, nil
+}
+
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(path)
+}
+
+func displayName(path string) string {
+	if path == "-" {
+		return "<stdin>"
+	}
+	return path
+}
+
+// loadConfig reads a config in any supported format, chosen by extension.
+func loadConfig(path string) (*dml.Config, error) {
+	content, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := dml.New()
+	switch formatFromExt(path) {
+	case "json":
+		err = cfg.FromJSON(string(content))
+	case "yaml":
+		_, err = cfg.FromYAML(string(content))
+	case "toml":
+		_, err = cfg.FromTOML(string(content))
+	case "ini":
+		_, err = cfg.FromINI(string(content))
+	default:
+		err = cfg.Parse(string(content))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func printJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(jsonValue(v))
+}
+
+// jsonValue converts values that encoding/json would render unhelpfully,
+// such as durations, into their DML text form.
+func jsonValue(v any) any {
+	switch val := v.(type) {
+	case time.Duration:
+		return val.String()
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = jsonValue(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = jsonValue(item)
+		}
+		return out
+	}
+	return v
+}
+
+func formatValue(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case time.Duration:
+		return val.String()
+	}
+	b, _ := json.Marshal(jsonValue(v))
+	return string(b)
+}
+
+func errorJSON(err error) map[string]any {
+	var dmlErr *dml.DMLError
+	if errors.As(err, &dmlErr) {
+		return map[string]any{
+			"type":    dmlErr.Type.String(),
+			"code":    dmlErr.Code,
+			"message": dmlErr.Message,
+			"line":    dmlErr.Line,
+			"column":  dmlErr.Column,
+		}
+	}
+	return map[string]any{"message": err.Error()}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
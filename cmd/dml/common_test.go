@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"reflect"
+	"testing"
+)
+
+func TestParseArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    []string
+		json    bool
+		prefix  string
+		wantErr bool
+	}{
+		{name: "positionals only", args: []string{"a.dml", "port"}, want: []string{"a.dml", "port"}},
+		{name: "flag first", args: []string{"--json", "a.dml"}, want: []string{"a.dml"}, json: true},
+		{name: "flag last", args: []string{"a.dml", "port", "--json"}, want: []string{"a.dml", "port"}, json: true},
+		{name: "flag between", args: []string{"a.dml", "--prefix", "APP", "port"}, want: []string{"a.dml", "port"}, prefix: "APP"},
+		{name: "flag with equals", args: []string{"a.dml", "--prefix=APP"}, want: []string{"a.dml"}, prefix: "APP"},
+		{name: "stdin", args: []string{"-", "--json"}, want: []string{"-"}, json: true},
+		{name: "negative int", args: []string{"a.dml", "offset", "-5"}, want: []string{"a.dml", "offset", "-5"}},
+		{name: "negative float before flag", args: []string{"a.dml", "ratio", "-0.5", "--json"}, want: []string{"a.dml", "ratio", "-0.5"}, json: true},
+		{name: "negative duration", args: []string{"a.dml", "skew", "-30s"}, want: []string{"a.dml", "skew", "-30s"}},
+		{name: "double dash", args: []string{"--json", "--", "a.dml", "--prefix"}, want: []string{"a.dml", "--prefix"}, json: true},
+		{name: "double dash after positional", args: []string{"a.dml", "--", "-x"}, want: []string{"a.dml", "-x"}},
+		{name: "no arguments", args: nil, want: nil},
+		{name: "unknown flag", args: []string{"a.dml", "--bogus"}, wantErr: true},
+		{name: "missing flag value", args: []string{"a.dml", "--prefix"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			asJSON := fs.Bool("json", false, "")
+			prefix := fs.String("prefix", "", "")
+
+			got, err := parseArgs(fs, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("expected an error, got %q", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseArgs: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("positionals: want %q, got %q", tt.want, got)
+			}
+			if *asJSON != tt.json {
+				t.Errorf("--json: want %v, got %v", tt.json, *asJSON)
+			}
+			if *prefix != tt.prefix {
+				t.Errorf("--prefix: want %q, got %q", tt.prefix, *prefix)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+const convertUsage = "dml convert [--from dml|json|yaml|toml|ini] --to dml|json|yaml|toml|ini [-o file] <file|->"
+
+func runConvert(args []string) int {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	from := fs.String("from", "", "input format (default: inferred from extension)")
+	to := fs.String("to", "dml", "output format")
+	out := fs.String("o", "", "output file (default stdout)")
+	pos, err := parseArgs(fs, args)
+	if err != nil || len(pos) != 1 {
+		return usageError(convertUsage, err)
+	}
+
+	path := pos[0]
+	content, err := readInput(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+
+	if *from == "" {
+		*from = formatFromExt(path)
+	}
+
+	cfg := dml.New()
+	var warnings []dml.ConvertWarning
+	switch strings.ToLower(*from) {
+	case "dml":
+		err = cfg.Parse(string(content))
+	case "json":
+		err = cfg.FromJSON(string(content))
+	case "yaml", "yml":
+		warnings, err = cfg.FromYAML(string(content))
+	case "toml":
+		warnings, err = cfg.FromTOML(string(content))
+	case "ini":
+		warnings, err = cfg.FromINI(string(content))
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown input format %q\n", *from)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFindings
+	}
+
+	var result string
+	var outWarnings []dml.ConvertWarning
+	switch strings.ToLower(*to) {
+	case "dml":
+		result, err = cfg.ToDML()
+	case "json":
+		result, err = cfg.ToJSON()
+		result += "\n"
+	case "yaml", "yml":
+		result, outWarnings, err = cfg.ToYAML()
+	case "toml":
+		result, outWarnings, err = cfg.ToTOML()
+	case "ini":
+		result, outWarnings, err = cfg.ToINI()
+	default:
+		fmt.Fprintf(os.Stderr, "Error: unknown output format %q\n", *to)
+		return exitUsage
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFindings
+	}
+
+	for _, w := range append(warnings, outWarnings...) {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", w)
+	}
+
+	if *out == "" {
+		fmt.Print(result)
+		return exitOK
+	}
+	if err := os.WriteFile(*out, []byte(result), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	return exitOK
+}
+
+func formatFromExt(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	case ".toml":
+		return "toml"
+	case ".ini", ".cfg":
+		return "ini"
+	}
+	return "dml"
+}
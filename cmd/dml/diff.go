@@ -0,0 +1,114 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+const diffUsage = "dml diff [--format text|json|patch] [--json] [--color auto|always|never] <old> <new>"
+
+const (
+	ansiRed    = "\033[31m"
+	ansiGreen  = "\033[32m"
+	ansiYellow = "\033[33m"
+	ansiReset  = "\033[0m"
+)
+
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	format := fs.String("format", "text", "output format: text, json or patch (RFC 6902)")
+	asJSON := fs.Bool("json", false, "shorthand for --format json")
+	color := fs.String("color", "auto", "colorize text output: auto, always or never")
+	pos, err := parseArgs(fs, args)
+	if err != nil || len(pos) != 2 {
+		return usageError(diffUsage, err)
+	}
+	if *asJSON {
+		*format = "json"
+	}
+
+	oldCfg, err := loadConfig(pos[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(pos[0]), err)
+		return exitUsage
+	}
+	newCfg, err := loadConfig(pos[1])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(pos[1]), err)
+		return exitUsage
+	}
+
+	changes := dml.Diff(oldCfg, newCfg)
+
+	switch *format {
+	case "text":
+		useColor, err := colorEnabled(*color)
+		if err != nil {
+			return usageError(diffUsage, err)
+		}
+		printChanges(changes, useColor)
+	case "json":
+		if changes == nil {
+			changes = []dml.Change{}
+		}
+		printJSON(changes)
+	case "patch":
+		patch, err := dml.JSONPatch(changes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+		fmt.Println(string(patch))
+	default:
+		return usageError(diffUsage, fmt.Errorf("unknown format %q", *format))
+	}
+
+	if len(changes) > 0 {
+		return exitFindings
+	}
+	return exitOK
+}
+
+func printChanges(changes []dml.Change, useColor bool) {
+	paint := func(color, s string) string {
+		if !useColor {
+			return s
+		}
+		return color + s + ansiReset
+	}
+
+	for _, c := range changes {
+		switch c.Kind {
+		case dml.ChangeAdded:
+			fmt.Println(paint(ansiGreen, fmt.Sprintf("+ %s = %s", c.Key, formatValue(c.New))))
+		case dml.ChangeRemoved:
+			fmt.Println(paint(ansiRed, fmt.Sprintf("- %s = %s", c.Key, formatValue(c.Old))))
+		case dml.ChangeType:
+			fmt.Println(paint(ansiYellow, fmt.Sprintf("~ %s: %s %s -> %s %s (type changed)",
+				c.Key, c.OldType, formatValue(c.Old), c.NewType, formatValue(c.New))))
+		default:
+			fmt.Println(paint(ansiYellow, fmt.Sprintf("~ %s: %s -> %s", c.Key, formatValue(c.Old), formatValue(c.New))))
+		}
+	}
+}
+
+// colorEnabled resolves --color; "auto" colors only terminals and honours
+// NO_COLOR.
+func colorEnabled(mode string) (bool, error) {
+	switch mode {
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
+	case "auto":
+		if os.Getenv("NO_COLOR") != "" {
+			return false, nil
+		}
+		info, err := os.Stdout.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0, nil
+	}
+	return false, fmt.Errorf("unknown color mode %q", mode)
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+const envUsage = "dml env [--cascade name] [--env-file file]... [--prefix P] [--create-keys] [--json] <file>\n       dml env list [--prefix P] [--format markdown|example|json] <file>"
+
+func runEnv(args []string) int {
+	if len(args) > 0 && args[0] == "list" {
+		return runEnvList(args[1:])
+	}
+
+	fs := flag.NewFlagSet("env", flag.ContinueOnError)
+	var envFiles stringList
+	cascade := fs.String("cascade", "", "load .env, .env.local, .env.<name> and .env.<name>.local next to the config")
+	fs.Var(&envFiles, "env-file", "load variables from a .env file (repeatable)")
+	prefix := fs.String("prefix", "", "apply EnvOverride with this prefix")
+	createKeys := fs.Bool("create-keys", false, "add keys for prefixed variables that match none")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	pos, err := parseArgs(fs, args)
+	if err != nil || len(pos) != 1 {
+		return usageError(envUsage, err)
+	}
+
+	// Variables from files go to files, not the process environment. The
+	// process environment beats the cascade, and --env-file beats both.
+	files := dml.NewMapEnv(nil)
+	env := dml.LayeredEnv(files, readOnlyEnv{dml.OSEnv()})
+	if *cascade != "" {
+		opts := dml.EnvCascadeOptions{Env: env}
+		if _, err := dml.LoadEnvCascadeWithOptions(filepath.Dir(pos[0]), *cascade, opts); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+	}
+	for _, f := range envFiles {
+		fileEnv, err := dml.ReadEnvFile(f, env)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+		for name, value := range fileEnv.Vars() {
+			files.Setenv(name, value)
+		}
+	}
+
+	cfg, err := loadConfig(pos[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(pos[0]), err)
+		return exitFindings
+	}
+	cfg.SetEnv(env)
+
+	if err := cfg.LoadWithEnv(); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(pos[0]), err)
+		return exitFindings
+	}
+	if *prefix != "" {
+		report := cfg.EnvOverrideWithOptions(dml.EnvOverrideOptions{Prefix: *prefix, CreateKeys: *createKeys})
+		for _, a := range report.Ignored {
+			fmt.Fprintf(os.Stderr, "warning: %s ignored: %s\n", a.Var, a.Reason)
+		}
+	}
+
+	return writeConfig(cfg, *asJSON, "")
+}
+
+// readOnlyEnv hides Setenv, so that LayeredEnv writes skip the layer.
+type readOnlyEnv struct {
+	dml.Env
+}
+
+// runEnvList prints the environment variables a config understands: those
+// EnvOverride reads with the prefix and those referenced with ${VAR}.
+func runEnvList(args []string) int {
+	fs := flag.NewFlagSet("env list", flag.ContinueOnError)
+	prefix := fs.String("prefix", "", "prefix EnvOverride uses for variable names")
+	format := fs.String("format", "markdown", "output format: markdown, example or json")
+	pos, err := parseArgs(fs, args)
+	if err != nil || len(pos) != 1 {
+		return usageError(envUsage, err)
+	}
+
+	cfg, err := loadConfig(pos[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(pos[0]), err)
+		return exitFindings
+	}
+
+	vars := cfg.EnvVars(*prefix)
+	switch *format {
+	case "markdown", "md":
+		err = dml.WriteEnvMarkdown(os.Stdout, vars)
+	case "example", "dotenv":
+		err = dml.WriteEnvExample(os.Stdout, vars)
+	case "json":
+		printJSON(vars)
+	default:
+		return usageError(envUsage, fmt.Errorf("unknown format %q", *format))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	return exitOK
+}
+
+func writeConfig(cfg *dml.Config, asJSON bool, out string) int {
+	var result string
+	if asJSON {
+		s, err := cfg.ToJSON()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitFindings
+		}
+		result = s + "\n"
+	} else {
+		s, err := cfg.ToDML()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitFindings
+		}
+		result = s
+	}
+
+	if out == "" {
+		fmt.Print(result)
+		return exitOK
+	}
+	if err := os.WriteFile(out, []byte(result), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	return exitOK
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunEnv_LeavesProcessEnvironmentAlone(t *testing.T) {
+	t.Setenv("DML_CLI_HOST", "process-host")
+	dir := writeTestFiles(t, map[string]string{
+		"app.dml":  "string host = \"${DML_CLI_HOST}\";\nstring region = \"${DML_CLI_REGION}\";\nint port = ${DML_CLI_PORT};\n",
+		".env":     "DML_CLI_HOST=file-host\nDML_CLI_REGION=eu-${DML_CLI_HOST}\n",
+		"port.env": "DML_CLI_PORT=9000\n",
+	})
+
+	var exit int
+	stdout, stderr := captureOutput(t, func() {
+		exit = runEnv([]string{"--cascade", "dev", "--env-file", filepath.Join(dir, "port.env"), "--json", filepath.Join(dir, "app.dml")})
+	})
+	if exit != exitOK {
+		t.Fatalf("want exit %d, got %d: %s", exitOK, exit, stderr)
+	}
+	var got map[string]any
+	if err := json.Unmarshal([]byte(stdout), &got); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	if got["host"] != "process-host" || got["region"] != "eu-process-host" || got["port"] != 9000.0 {
+		t.Errorf("unexpected result: %v", got)
+	}
+	for _, name := range []string{"DML_CLI_REGION", "DML_CLI_PORT"} {
+		if v, set := os.LookupEnv(name); set {
+			t.Errorf("%s was set in the process environment to %q", name, v)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+var envRefRe = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)[^}]*\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+
+const explainUsage = "dml explain [--json] <file> <key>"
+
+func runExplain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the explanation as JSON")
+	pos, err := parseArgs(fs, args)
+	if err != nil || len(pos) != 2 {
+		return usageError(explainUsage, err)
+	}
+
+	path, key := pos[0], pos[1]
+	cfg, err := loadConfig(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(path), err)
+		return exitFindings
+	}
+
+	val, ok := cfg.Get(key)
+	if !ok {
+		fmt.Fprintf(os.Stderr, "key '%s' not found\n", key)
+		return exitFindings
+	}
+
+	info := map[string]any{
+		"key":   key,
+		"value": jsonValue(val),
+	}
+	if typ, ok := cfg.DeclaredType(key); ok {
+		info["type"] = typ
+	}
+
+	if decl, found := findDeclaration(cfg.Declarations(), key); found {
+		declInfo := map[string]any{
+			"name":   decl.Name,
+			"type":   decl.Type,
+			"file":   displayName(path),
+			"line":   decl.Line,
+			"column": decl.Column,
+		}
+		if decl.Doc != "" {
+			declInfo["doc"] = decl.Doc
+		}
+		info["declaration"] = declInfo
+	}
+
+	if refs := envRefs(val); len(refs) > 0 {
+		info["env"] = refs
+	}
+
+	if *asJSON {
+		printJSON(info)
+		return exitOK
+	}
+
+	fmt.Printf("%s = %s\n", key, formatValue(val))
+	if typ, ok := info["type"]; ok {
+		fmt.Printf("  type:        %s\n", typ)
+	}
+	if d, ok := info["declaration"].(map[string]any); ok {
+		where := fmt.Sprintf("%s:%d:%d", d["file"], d["line"], d["column"])
+		if d["name"] != key {
+			where += fmt.Sprintf(" (inside %s %s)", d["type"], d["name"])
+		}
+		fmt.Printf("  declared at: %s\n", where)
+		if doc, ok := d["doc"].(string); ok {
+			fmt.Printf("  doc:         %s\n", strings.ReplaceAll(doc, "\n", "\n               "))
+		}
+	}
+	if refs, ok := info["env"].([]string); ok {
+		fmt.Printf("  env:         %s\n", strings.Join(refs, ", "))
+	}
+	return exitOK
+}
+
+// findDeclaration returns the last declaration of key, or of the closest
+// parent whose map literal contains it.
+func findDeclaration(decls []dml.Declaration, key string) (dml.Declaration, bool) {
+	for candidate := key; candidate != ""; {
+		for i := len(decls) - 1; i >= 0; i-- {
+			if decls[i].Name == candidate {
+				return decls[i], true
+			}
+		}
+		dot := strings.LastIndexByte(candidate, '.')
+		if dot < 0 {
+			break
+		}
+		candidate = candidate[:dot]
+	}
+	return dml.Declaration{}, false
+}
+
+func envRefs(v any) []string {
+	seen := map[string]bool{}
+	var walk func(any)
+	walk = func(v any) {
+		switch val := v.(type) {
+		case string:
+			for _, m := range envRefRe.FindAllStringSubmatch(val, -1) {
+				name := m[1]
+				if name == "" {
+					name = m[2]
+				}
+				seen[name] = true
+			}
+		case []any:
+			for _, item := range val {
+				walk(item)
+			}
+		case map[string]any:
+			for _, item := range val {
+				walk(item)
+			}
+		}
+	}
+	walk(v)
+
+	refs := make([]string, 0, len(seen))
+	for name := range seen {
+		refs = append(refs, name)
+	}
+	sort.Strings(refs)
+	return refs
+}
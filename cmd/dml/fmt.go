@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+const fmtUsage = "dml fmt [-w] [-d] [-sort] [files...]"
+
+func runFmt(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	write := fs.Bool("w", false, "write result to the source file instead of stdout")
+	diff := fs.Bool("d", false, "display diffs instead of rewriting files")
+	sortKeys := fs.Bool("sort", false, "sort keys inside map literals")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(fmtUsage, err)
+	}
+	if files, err = expandFiles(files); err != nil {
+		return usageError(fmtUsage, err)
+	}
+	opts := dml.FormatOptions{SortKeys: *sortKeys}
+
+	if len(files) == 0 || (len(files) == 1 && files[0] == "-") {
+		if *write {
+			fmt.Fprintln(os.Stderr, "Error: cannot use -w with standard input")
+			return exitUsage
+		}
+		src, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+		return formatOne("<stdin>", src, opts, false, *diff)
+	}
+
+	exit := exitOK
+	for _, path := range files {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exit = exitUsage
+			continue
+		}
+		if code := formatOne(path, src, opts, *write, *diff); code > exit {
+			exit = code
+		}
+	}
+	return exit
+}
+
+func formatOne(path string, src []byte, opts dml.FormatOptions, write, diff bool) int {
+	out, err := dml.FormatWithOptions(src, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+		return exitFindings
+	}
+
+	if diff {
+		if !bytes.Equal(src, out) {
+			fmt.Print(unifiedDiff(path, string(src), string(out)))
+		}
+		return exitOK
+	}
+	if write {
+		if bytes.Equal(src, out) {
+			return exitOK
+		}
+		if err := os.WriteFile(path, out, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+		return exitOK
+	}
+	os.Stdout.Write(out)
+	return exitOK
+}
+
+// unifiedDiff renders a line-based diff between a and b with three lines of
+// context around each change.
+func unifiedDiff(path, a, b string) string {
+	x := splitLines(a)
+	y := splitLines(b)
+
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			if x[i] == y[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else if lcs[i+1][j] >= lcs[i][j+1] {
+				lcs[i][j] = lcs[i+1][j]
+			} else {
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+
+	type op struct {
+		kind byte
+		text string
+		i, j int
+	}
+	var ops []op
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			ops = append(ops, op{' ', x[i], i, j})
+			i++
+			j++
+		case i < len(x) && (j == len(y) || lcs[i+1][j] >= lcs[i][j+1]):
+			ops = append(ops, op{'-', x[i], i, j})
+			i++
+		default:
+			ops = append(ops, op{'+', y[j], i, j})
+			j++
+		}
+	}
+
+	const context = 3
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "--- %s.orig\n+++ %s\n", path, path)
+	for k := 0; k < len(ops); {
+		if ops[k].kind == ' ' {
+			k++
+			continue
+		}
+		start := k - context
+		if start < 0 {
+			start = 0
+		}
+		end := k
+		for end < len(ops) {
+			if ops[end].kind != ' ' {
+				end++
+				continue
+			}
+			run := end
+			for run < len(ops) && ops[run].kind == ' ' {
+				run++
+			}
+			if run-end > 2*context || run == len(ops) {
+				end += context
+				if end > len(ops) {
+					end = len(ops)
+				}
+				break
+			}
+			end = run
+		}
+
+		oldCount, newCount := 0, 0
+		for _, o := range ops[start:end] {
+			if o.kind != '+' {
+				oldCount++
+			}
+			if o.kind != '-' {
+				newCount++
+			}
+		}
+		fmt.Fprintf(&sb, "@@ -%d,%d +%d,%d @@\n", ops[start].i+1, oldCount, ops[start].j+1, newCount)
+		for _, o := range ops[start:end] {
+			fmt.Fprintf(&sb, "%c%s\n", o.kind, o.text)
+		}
+		k = end
+	}
+	return sb.String()
+}
+
+func splitLines(s string) []string {
+	s = strings.TrimSuffix(s, "\n")
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, "\n")
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+const genUsage = "dml gen go [-pkg name] [-type Name] [-o file.go] <file.dml>"
+
+func runGen(args []string) int {
+	if len(args) == 0 || args[0] != "go" {
+		return usageError(genUsage, nil)
+	}
+
+	fs := flag.NewFlagSet("gen go", flag.ContinueOnError)
+	pkg := fs.String("pkg", os.Getenv("GOPACKAGE"), "package name of the generated file")
+	typeName := fs.String("type", "Config", "name of the generated struct")
+	out := fs.String("o", "", "output file (default stdout)")
+	pos, err := parseArgs(fs, args[1:])
+	if err != nil || len(pos) != 1 {
+		return usageError(genUsage, err)
+	}
+
+	path := pos[0]
+	cfg, err := dml.NewConfig(path)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFindings
+	}
+
+	src, err := dml.GenerateGo(cfg, dml.GenerateOptions{
+		Package:  *pkg,
+		TypeName: *typeName,
+		Source:   filepath.Base(path),
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFindings
+	}
+
+	if *out == "" {
+		os.Stdout.Write(src)
+		return exitOK
+	}
+	if err := os.WriteFile(*out, src, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFindings
+	}
+	return exitOK
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+const lintUsage = "dml lint [--fix] [--format text|json|jsonl|sarif|checkstyle] [--config .dmllint] [--list-rules] <files...>"
+
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "shorthand for --format json")
+	format := fs.String("format", "text", "output format: "+reportFormats)
+	configPath := fs.String("config", "", "lint configuration file (default: nearest "+dml.LintConfigFile+")")
+	listRules := fs.Bool("list-rules", false, "list registered rules and exit")
+	fix := fs.Bool("fix", false, "apply suggested fixes in place (stdin: print the fixed source)")
+	files, err := parseArgs(fs, args)
+	if err != nil {
+		return usageError(lintUsage, err)
+	}
+
+	if *listRules {
+		for _, r := range dml.LintRules() {
+			fmt.Printf("%-20s %-8s %s\n", r.Code(), r.DefaultLevel(), r.Description())
+		}
+		return exitOK
+	}
+
+	if len(files) == 0 {
+		return usageError(lintUsage, nil)
+	}
+	if *format, err = reportFormat(*format, *asJSON); err != nil {
+		return usageError(lintUsage, err)
+	}
+	if files, err = expandFiles(files); err != nil {
+		return usageError(lintUsage, err)
+	}
+
+	exit := exitOK
+	// With --fix on stdin the fixed source goes to stdout, so the report
+	// is written to stderr.
+	out := io.Writer(os.Stdout)
+	var findings []dml.Finding
+	for _, path := range files {
+		// A file that cannot be linted is reported and the rest are still
+		// checked; the exit code says an error happened.
+		fail := func(finding dml.Finding, err error) {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(path), err)
+			exit = exitUsage
+			findings = append(findings, finding)
+		}
+
+		opts, err := lintOptionsFor(path, *configPath)
+		if err != nil {
+			fail(dml.ErrorFinding(displayName(path), err), err)
+			continue
+		}
+
+		content, err := readInput(path)
+		if err != nil {
+			finding := dml.ErrorFinding(displayName(path), err)
+			finding.Code = "READ_ERROR"
+			fail(finding, err)
+			continue
+		}
+
+		if *fix {
+			fixed, n, err := dml.FixSource(content, opts)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "%s: not fixed: %v\n", displayName(path), err)
+			}
+			if path == "-" {
+				os.Stdout.Write(fixed)
+				out = os.Stderr
+			} else if n > 0 {
+				if err := os.WriteFile(path, fixed, 0644); err != nil {
+					fail(dml.ErrorFinding(displayName(path), err), err)
+					continue
+				}
+				fmt.Fprintf(os.Stderr, "%s: fixed %d issue(s)\n", displayName(path), n)
+			}
+			content = fixed
+		}
+
+		issues, err := dml.LintSourceWithOptions(content, opts)
+		if err != nil {
+			fail(dml.ErrorFinding(displayName(path), err), err)
+			continue
+		}
+
+		for _, it := range issues {
+			if it.Level == dml.LintLevelError && exit < exitFindings {
+				exit = exitFindings
+			}
+			findings = append(findings, it.Finding(displayName(path)))
+		}
+	}
+
+	if err := writeFindings(out, *format, findings); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitUsage
+	}
+	return exit
+}
+
+// lintOptionsFor loads the explicit config, or the .dmllint nearest to path.
+func lintOptionsFor(path, explicit string) (dml.LintOptions, error) {
+	if explicit != "" {
+		return dml.LoadLintConfig(explicit)
+	}
+	dir := "."
+	if path != "-" {
+		dir = filepath.Dir(path)
+	}
+	if found, ok := dml.FindLintConfig(dir); ok {
+		return dml.LoadLintConfig(found)
+	}
+	return dml.LintOptions{}, nil
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunLint_ContinuesAfterMissingFile(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"good.dml": "int port = 8080;\n",
+		"bad.dml":  "string db_password = \"hunter2\";\n",
+	})
+	missing := filepath.Join(dir, "missing.dml")
+	args := []string{filepath.Join(dir, "good.dml"), missing, filepath.Join(dir, "bad.dml")}
+
+	var exit int
+	stdout, stderr := captureOutput(t, func() { exit = runLint(append([]string{"--format", "json"}, args...)) })
+	if exit != exitUsage {
+		t.Errorf("want exit %d, got %d", exitUsage, exit)
+	}
+	if !strings.Contains(stderr, "missing.dml") {
+		t.Errorf("expected the read error on stderr, got %q", stderr)
+	}
+	var findings []struct {
+		File string `json:"file"`
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &findings); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	codes := make(map[string]string)
+	for _, f := range findings {
+		codes[f.Code] = filepath.Base(f.File)
+	}
+	if codes["READ_ERROR"] != "missing.dml" || codes["SECRET_KEY_LITERAL"] != "bad.dml" {
+		t.Errorf("expected the read error and the later file's findings, got:\n%s", stdout)
+	}
+
+	stdout, _ = captureOutput(t, func() { exit = runLint(args[:2]) })
+	if exit != exitUsage {
+		t.Errorf("want exit %d for good and missing files, got %d", exitUsage, exit)
+	}
+	if !strings.Contains(stdout, "missing.dml") {
+		t.Errorf("expected the report to include the missing file:\n%s", stdout)
+	}
+}
@@ -7,21 +7,89 @@ import (
 	"github.com/tree-software-company/dml-go/dml"
 )
 
+// Exit codes shared by every command.
+const (
+	exitOK       = 0
+	exitFindings = 1
+	exitUsage    = 2
+)
+
+type command struct {
+	name    string
+	usage   string
+	summary string
+	run     func(args []string) int
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"validate", validateUsage, "parse files and report errors", runValidate},
+		{"lint", lintUsage, "run static checks configured by .dmllint", runLint},
+		{"fmt", fmtUsage, "format files canonically", runFmt},
+		{"get", getUsage, "print a value", runGet},
+		{"set", setUsage, "change a value, keeping the file's layout", runSet},
+		{"query", queryUsage, "print every value matching a path expression", runQuery},
+		{"keys", keysUsage, "list keys", runKeys},
+		{"convert", convertUsage, "convert between DML, JSON, YAML, TOML and INI", runConvert},
+		{"diff", diffUsage, "show semantic changes between two configs", runDiff},
+		{"merge", mergeUsage, "deep-merge configs, later files win by default", runMerge},
+		{"env", envUsage, "show a config after env interpolation and overrides", runEnv},
+		{"explain", explainUsage, "show where a key is declared and what it depends on", runExplain},
+		{"gen", genUsage, "generate code from a config", runGen},
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: dml <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, c := range commands {
+		fmt.Fprintf(os.Stderr, "  %-9s %s\n", c.name, c.summary)
+	}
+	fmt.Fprintln(os.Stderr, "\nExit codes: 0 success, 1 invalid input, issues or differences found, 2 usage or I/O error.")
+	fmt.Fprintln(os.Stderr, "Use \"-\" as a file name to read from stdin.")
+}
+
 func main() {
 	if len(os.Args) < 2 {
-		fmt.Println("Usage: dml <file.dml>")
-		os.Exit(1)
+		usage()
+		os.Exit(exitUsage)
 	}
 
-	filepath := os.Args[1]
+	dml.SetLocale(dml.LocaleFromEnv(os.Getenv))
+
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "--help" {
+		usage()
+		os.Exit(exitOK)
+	}
+
+	for _, c := range commands {
+		if c.name == name {
+			os.Exit(c.run(os.Args[2:]))
+		}
+	}
+
+	// Backwards compatible form: dml <file.dml>
+	if _, err := os.Stat(name); err == nil {
+		os.Exit(runDump(name))
+	}
+
+	fmt.Fprintf(os.Stderr, "dml: unknown command %q\n\n", name)
+	usage()
+	os.Exit(exitUsage)
+}
 
-	cfg, err := dml.NewConfig(filepath)
+func runDump(path string) int {
+	cfg, err := dml.NewConfig(path)
 	if err != nil {
 		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		return exitFindings
 	}
 
 	fmt.Println("✅ DML file parsed successfully!")
 	fmt.Println("\n📄 Config dump:")
 	fmt.Println(cfg.Dump())
+	return exitOK
 }
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+const mergeUsage = "dml merge [--lists replace|append|unique] [--conflict last|first|error] [--types error|override] [--json] [-o file] <files...>"
+
+func runMerge(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ContinueOnError)
+	lists := fs.String("lists", "replace", "list strategy: replace, append or unique")
+	conflict := fs.String("conflict", "last", "conflict strategy: last, first or error")
+	types := fs.String("types", "error", "type mismatch strategy: error or override")
+	asJSON := fs.Bool("json", false, "print the merged config as JSON")
+	out := fs.String("o", "", "output file (default stdout)")
+	files, err := parseArgs(fs, args)
+	if err != nil || len(files) < 2 {
+		return usageError(mergeUsage, err)
+	}
+	if files, err = expandFiles(files); err != nil {
+		return usageError(mergeUsage, err)
+	}
+
+	opts, err := mergeOptions(*lists, *conflict, *types)
+	if err != nil {
+		return usageError(mergeUsage, err)
+	}
+
+	configs := make([]*dml.Config, len(files))
+	for i, path := range files {
+		if configs[i], err = loadConfig(path); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(path), err)
+			return exitFindings
+		}
+	}
+
+	conflicts, err := dml.Merge(configs[0], opts, configs[1:]...)
+	for _, c := range conflicts {
+		fmt.Fprintf(os.Stderr, "conflict: %s (from %s)\n", c, displayName(files[c.Source+1]))
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return exitFindings
+	}
+
+	return writeConfig(configs[0], *asJSON, *out)
+}
+
+func mergeOptions(lists, conflict, types string) (dml.MergeOptions, error) {
+	var opts dml.MergeOptions
+	switch lists {
+	case "replace":
+		opts.Lists = dml.ListReplace
+	case "append":
+		opts.Lists = dml.ListAppend
+	case "unique":
+		opts.Lists = dml.ListUniqueAppend
+	default:
+		return opts, fmt.Errorf("unknown list strategy %q", lists)
+	}
+	switch conflict {
+	case "last":
+		opts.Conflict = dml.ConflictLastWins
+	case "first":
+		opts.Conflict = dml.ConflictFirstWins
+	case "error":
+		opts.Conflict = dml.ConflictError
+	default:
+		return opts, fmt.Errorf("unknown conflict strategy %q", conflict)
+	}
+	switch types {
+	case "error":
+		opts.TypeMismatch = dml.TypeMismatchError
+	case "override":
+		opts.TypeMismatch = dml.TypeMismatchOverride
+	default:
+		return opts, fmt.Errorf("unknown type mismatch strategy %q", types)
+	}
+	return opts, nil
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+const (
+	getUsage   = "dml get [--json] <file> <key>"
+	setUsage   = "dml set [--string] <file> <key> <value>"
+	keysUsage  = "dml keys [--all] [--json] <file>"
+	queryUsage = "dml query [--json] <file> <expr>"
+)
+
+func runGet(args []string) int {
+	fs := flag.NewFlagSet("get", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print the value as JSON")
+	pos, err := parseArgs(fs, args)
+	if err != nil || len(pos) != 2 {
+		return usageError(getUsage, err)
+	}
+
+	cfg, err := loadConfig(pos[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(pos[0]), err)
+		return exitFindings
+	}
+
+	val, ok := cfg.Get(pos[1])
+	if !ok {
+		fmt.Fprintf(os.Stderr, "key '%s' not found\n", pos[1])
+		return exitFindings
+	}
+
+	if *asJSON {
+		printJSON(val)
+	} else {
+		fmt.Println(formatValue(val))
+	}
+	return exitOK
+}
+
+func runSet(args []string) int {
+	fs := flag.NewFlagSet("set", flag.ContinueOnError)
+	asString := fs.Bool("string", false, "store the value as a string without interpreting it")
+	pos, err := parseArgs(fs, args)
+	if err != nil || len(pos) != 3 {
+		return usageError(setUsage, err)
+	}
+
+	path, key := pos[0], pos[1]
+	var value any = pos[2]
+	if !*asString {
+		value = dml.ParseValue(pos[2])
+	}
+
+	if path == "-" {
+		content, err := readInput(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+		out, err := dml.EditSource(content).Set(key, value).Bytes()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitFindings
+		}
+		os.Stdout.Write(out)
+		return exitOK
+	}
+
+	if err := dml.Edit(path).Set(key, value).Save(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if os.IsNotExist(err) {
+			return exitUsage
+		}
+		return exitFindings
+	}
+	return exitOK
+}
+
+func runKeys(args []string) int {
+	fs := flag.NewFlagSet("keys", flag.ContinueOnError)
+	all := fs.Bool("all", false, "list every leaf key as a dotted path")
+	asJSON := fs.Bool("json", false, "print keys as a JSON array")
+	pos, err := parseArgs(fs, args)
+	if err != nil || len(pos) != 1 {
+		return usageError(keysUsage, err)
+	}
+
+	cfg, err := loadConfig(pos[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(pos[0]), err)
+		return exitFindings
+	}
+
+	keys := cfg.Keys()
+	if *all {
+		keys = sortedKeys(cfg.Flatten())
+	}
+
+	if *asJSON {
+		printJSON(keys)
+		return exitOK
+	}
+	for _, k := range keys {
+		fmt.Println(k)
+	}
+	return exitOK
+}
+
+func runQuery(args []string) int {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print matches as a JSON array")
+	pos, err := parseArgs(fs, args)
+	if err != nil || len(pos) != 2 {
+		return usageError(queryUsage, err)
+	}
+
+	cfg, err := loadConfig(pos[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(pos[0]), err)
+		return exitFindings
+	}
+
+	matches, err := cfg.Query(pos[1])
+	if err != nil {
+		return usageError(queryUsage, err)
+	}
+
+	if *asJSON {
+		printJSON(matches)
+	} else {
+		for _, m := range matches {
+			fmt.Println(formatValue(m))
+		}
+	}
+	if len(matches) == 0 {
+		return exitFindings
+	}
+	return exitOK
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+const reportFormats = "text, json, jsonl, sarif, checkstyle"
+
+// reportFormat validates --format, letting the older --json flag select JSON.
+func reportFormat(format string, asJSON bool) (string, error) {
+	if asJSON {
+		format = "json"
+	}
+	switch format {
+	case "text", "json", "jsonl", "sarif", "checkstyle":
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format %q (want %s)", format, reportFormats)
+}
+
+// writeFindings renders findings in one of the report formats.
+func writeFindings(w io.Writer, format string, findings []dml.Finding) error {
+	switch format {
+	case "json":
+		if findings == nil {
+			findings = []dml.Finding{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(findings)
+	case "jsonl":
+		return dml.WriteJSONLines(w, findings)
+	case "sarif":
+		return dml.WriteSARIF(w, findings)
+	case "checkstyle":
+		return dml.WriteCheckstyle(w, findings)
+	}
+	for _, f := range findings {
+		if _, err := fmt.Fprintf(w, "%s:%d:%d: %s %s %s\n", f.File, f.Line, f.Column, f.Level, f.Code, f.Message); err != nil {
+			return err
+		}
+	}
+	return nil
+}
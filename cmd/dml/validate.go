@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/tree-software-company/dml-go/dml"
+)
+
+const validateUsage = "dml validate [--strict] [--format text|json|jsonl|sarif|checkstyle] <files...>"
+
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "shorthand for --format json")
+	format := fs.String("format", "text", "output format: "+reportFormats)
+	strict := fs.Bool("strict", false, "reject keys that are declared twice or overwritten by a dotted key")
+	files, err := parseArgs(fs, args)
+	if err != nil || len(files) == 0 {
+		return usageError(validateUsage, err)
+	}
+	if *format, err = reportFormat(*format, *asJSON); err != nil {
+		return usageError(validateUsage, err)
+	}
+	if files, err = expandFiles(files); err != nil {
+		return usageError(validateUsage, err)
+	}
+
+	exit := exitOK
+	var results []map[string]any
+	var findings []dml.Finding
+	for _, path := range files {
+		result := map[string]any{"file": displayName(path), "valid": true}
+		content, err := readInput(path)
+		if err != nil {
+			// An unreadable file is reported and the rest are still checked;
+			// the exit code says an I/O error happened.
+			fmt.Fprintf(os.Stderr, "%s: %v\n", displayName(path), err)
+			exit = exitUsage
+			result["valid"] = false
+			result["error"] = map[string]any{"message": err.Error()}
+			findings = append(findings, dml.Finding{
+				File: displayName(path), Code: "READ_ERROR", Level: dml.LintLevelError, Message: err.Error(),
+				Line: 1, Column: 1, EndLine: 1, EndColumn: 1,
+			})
+			results = append(results, result)
+			continue
+		}
+
+		cfg := dml.New()
+		cfg.SetStrict(*strict)
+		if err := cfg.Parse(string(content)); err != nil {
+			if exit < exitFindings {
+				exit = exitFindings
+			}
+			result["valid"] = false
+			result["error"] = errorJSON(err)
+			findings = append(findings, dml.ErrorFinding(displayName(path), err))
+			if *format == "text" {
+				fmt.Printf("%s: %s\n", displayName(path), strings.TrimSpace(err.Error()))
+			}
+		} else if *format == "text" {
+			fmt.Printf("%s: ok\n", displayName(path))
+		}
+		results = append(results, result)
+	}
+
+	switch *format {
+	case "text":
+	case "json":
+		printJSON(results)
+	default:
+		if err := writeFindings(os.Stdout, *format, findings); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return exitUsage
+		}
+	}
+	return exit
+}
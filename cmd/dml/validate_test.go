@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureOutput runs fn with stdout and stderr redirected and returns what
+// was written to each.
+func captureOutput(t *testing.T, fn func()) (stdout, stderr string) {
+	t.Helper()
+	read := func(f **os.File) func() string {
+		r, w, err := os.Pipe()
+		if err != nil {
+			t.Fatalf("Pipe: %v", err)
+		}
+		orig := *f
+		*f = w
+		done := make(chan string)
+		go func() {
+			data, _ := io.ReadAll(r)
+			done <- string(data)
+		}()
+		return func() string {
+			w.Close()
+			*f = orig
+			return <-done
+		}
+	}
+	stopOut := read(&os.Stdout)
+	stopErr := read(&os.Stderr)
+	fn()
+	return stopOut(), stopErr()
+}
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile: %v", err)
+		}
+	}
+	return dir
+}
+
+func TestRunValidate_ExitCodes(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"good.dml": "int port = 8080;\n",
+		"bad.dml":  "int port = \"x\";\n",
+	})
+	good := filepath.Join(dir, "good.dml")
+	bad := filepath.Join(dir, "bad.dml")
+	missing := filepath.Join(dir, "missing.dml")
+
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{"valid", []string{good}, exitOK},
+		{"invalid", []string{good, bad}, exitFindings},
+		{"unreadable", []string{missing, good}, exitUsage},
+		{"unreadable wins over invalid", []string{bad, missing}, exitUsage},
+		{"no files", nil, exitUsage},
+		{"unknown flag", []string{"--bogus", good}, exitUsage},
+		{"unknown format", []string{"--format", "xml", good}, exitUsage},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got int
+			captureOutput(t, func() { got = runValidate(tt.args) })
+			if got != tt.want {
+				t.Errorf("want exit %d, got %d", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRunValidate_ContinuesAfterUnreadableFile(t *testing.T) {
+	dir := writeTestFiles(t, map[string]string{
+		"good.dml": "int port = 8080;\n",
+		"bad.dml":  "int port = \"x\";\n",
+	})
+	missing := filepath.Join(dir, "missing.dml")
+	args := []string{missing, filepath.Join(dir, "good.dml"), filepath.Join(dir, "bad.dml")}
+
+	stdout, stderr := captureOutput(t, func() { runValidate(append([]string{"--json"}, args...)) })
+	var results []map[string]any
+	if err := json.Unmarshal([]byte(stdout), &results); err != nil {
+		t.Fatalf("invalid JSON output: %v\n%s", err, stdout)
+	}
+	if len(results) != 3 {
+		t.Fatalf("expected a result for every file, got %d:\n%s", len(results), stdout)
+	}
+	if results[0]["valid"] != false || results[1]["valid"] != true || results[2]["valid"] != false {
+		t.Errorf("unexpected results:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, "missing.dml") {
+		t.Errorf("expected the read error on stderr, got %q", stderr)
+	}
+
+	stdout, _ = captureOutput(t, func() { runValidate(append([]string{"--format", "sarif"}, args...)) })
+	var sarif struct {
+		Runs []struct {
+			Results []struct {
+				RuleID string `json:"ruleId"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal([]byte(stdout), &sarif); err != nil {
+		t.Fatalf("invalid SARIF output: %v\n%s", err, stdout)
+	}
+	if len(sarif.Runs) != 1 || len(sarif.Runs[0].Results) != 2 || sarif.Runs[0].Results[0].RuleID != "READ_ERROR" {
+		t.Errorf("expected the read error and the parse error in SARIF:\n%s", stdout)
+	}
+
+	stdout, _ = captureOutput(t, func() { runValidate(args) })
+	if !strings.Contains(stdout, "good.dml: ok") || !strings.Contains(stdout, "bad.dml:") {
+		t.Errorf("expected text output for the readable files:\n%s", stdout)
+	}
+}
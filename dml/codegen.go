@@ -0,0 +1,322 @@
+package dml
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+type GenerateOptions struct {
+	Package  string
+	TypeName string
+	Source   string
+}
+
+type genField struct {
+	name string
+	typ  string
+	key  string
+	doc  string
+}
+
+type genStruct struct {
+	name   string
+	doc    string
+	fields []genField
+}
+
+type generator struct {
+	cfg        *Config
+	docs       map[string]string
+	structs    []*genStruct
+	used       map[string]bool
+	needsTime  bool
+	typeByPath map[string]string
+}
+
+var commonInitialisms = map[string]bool{
+	"API": true, "DB": true, "DNS": true, "HTTP": true, "HTTPS": true, "ID": true,
+	"IP": true, "JSON": true, "SQL": true, "SSL": true, "TLS": true, "TTL": true,
+	"URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// GenerateGo renders Go source for a struct mirroring cfg, together with a
+// Load function that parses a DML file into it.
+func GenerateGo(cfg *Config, opts GenerateOptions) ([]byte, error) {
+	if opts.Package == "" {
+		opts.Package = "config"
+	}
+	if opts.TypeName == "" {
+		opts.TypeName = "Config"
+	}
+
+	g := &generator{
+		cfg:        cfg,
+		docs:       make(map[string]string),
+		used:       map[string]bool{"Load": true, opts.TypeName: true},
+		typeByPath: make(map[string]string),
+	}
+	for _, d := range cfg.decls {
+		if d.Doc != "" {
+			g.docs[d.Name] = d.Doc
+		}
+	}
+
+	root := &genStruct{name: opts.TypeName}
+	if opts.Source != "" {
+		root.doc = fmt.Sprintf("%s mirrors %s.", opts.TypeName, opts.Source)
+	}
+	g.structs = append(g.structs, root)
+	if err := g.fillStruct(root, "", cfg.data, g.topLevelOrder()); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if opts.Source != "" {
+		fmt.Fprintf(&buf, "// Code generated by dml gen go from %s; DO NOT EDIT.\n\n", opts.Source)
+	} else {
+		buf.WriteString("// Code generated by dml gen go; DO NOT EDIT.\n\n")
+	}
+	fmt.Fprintf(&buf, "package %s\n\n", opts.Package)
+	buf.WriteString("import (\n")
+	if g.needsTime {
+		buf.WriteString("\t\"time\"\n\n")
+	}
+	buf.WriteString("\t\"github.com/tree-software-company/dml-go/dml\"\n)\n\n")
+
+	for _, s := range g.structs {
+		writeDoc(&buf, s.doc, "")
+		fmt.Fprintf(&buf, "type %s struct {\n", s.name)
+		for _, f := range s.fields {
+			writeDoc(&buf, f.doc, "\t")
+			fmt.Fprintf(&buf, "\t%s %s `dml:%q`\n", f.name, f.typ, f.key)
+		}
+		buf.WriteString("}\n\n")
+	}
+
+	fmt.Fprintf(&buf, "// Load parses the DML file at path into a %s.\n", opts.TypeName)
+	fmt.Fprintf(&buf, "func Load(path string) (*%s, error) {\n", opts.TypeName)
+	buf.WriteString("\tcfg, err := dml.NewConfig(path)\n\tif err != nil {\n\t\treturn nil, err\n\t}\n")
+	fmt.Fprintf(&buf, "\tvar out %s\n", opts.TypeName)
+	buf.WriteString("\tif err := cfg.Decode(&out); err != nil {\n\t\treturn nil, err\n\t}\n\treturn &out, nil\n}\n")
+
+	return format.Source(buf.Bytes())
+}
+
+func writeDoc(buf *bytes.Buffer, doc, indent string) {
+	if doc == "" {
+		return
+	}
+	for _, line := range strings.Split(doc, "\n") {
+		fmt.Fprintf(buf, "%s// %s\n", indent, line)
+	}
+}
+
+// topLevelOrder lists top-level keys in declaration order, followed by any
+// keys that were set without a declaration.
+func (g *generator) topLevelOrder() []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, d := range g.cfg.decls {
+		first := strings.Split(d.Name, ".")[0]
+		if _, ok := g.cfg.data[first]; ok && !seen[first] {
+			seen[first] = true
+			keys = append(keys, first)
+		}
+	}
+	for _, k := range g.cfg.sortedKeys(g.cfg.data) {
+		if !seen[k] {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+// fillStruct adds a field to s for each key of m. Nested maps only become
+// structs when structCompatible, so an invalid field name can only come from
+// a top-level key.
+func (g *generator) fillStruct(s *genStruct, path string, m map[string]any, keys []string) error {
+	names := make(map[string]bool)
+	for _, k := range keys {
+		full := joinKey(path, k)
+		name := exportedName(k)
+		if !isGoFieldName(name) {
+			return fmt.Errorf("cannot generate field for key %q: it must start with a letter that has an upper case", full)
+		}
+		for names[name] {
+			name += "_"
+		}
+		names[name] = true
+		s.fields = append(s.fields, genField{
+			name: name,
+			typ:  g.goType(full, m[k]),
+			key:  k,
+			doc:  g.docs[full],
+		})
+	}
+	return nil
+}
+
+func (g *generator) goType(path string, value any) string {
+	switch v := value.(type) {
+	case string:
+		if looksLikeDuration(v) {
+			g.needsTime = true
+			return "time.Duration"
+		}
+		return "string"
+	case int:
+		return "int"
+	case float64:
+		return "float64"
+	case bool:
+		return "bool"
+	case time.Duration:
+		g.needsTime = true
+		return "time.Duration"
+	case []any:
+		return "[]" + g.elemType(path, v)
+	case map[string]any:
+		if len(v) == 0 || !structCompatible(v) {
+			return "map[string]any"
+		}
+		name := g.structName(path)
+		s := &genStruct{name: name}
+		g.structs = append(g.structs, s)
+		g.fillStruct(s, path, v, g.cfg.sortedKeys(v))
+		return name
+	}
+	return "any"
+}
+
+func (g *generator) elemType(path string, list []any) string {
+	if len(list) == 0 {
+		return "any"
+	}
+
+	maps := make([]map[string]any, 0, len(list))
+	for _, item := range list {
+		if m, ok := item.(map[string]any); ok {
+			maps = append(maps, m)
+		}
+	}
+	if len(maps) == len(list) {
+		merged := make(map[string]any)
+		for _, m := range maps {
+			for k, v := range m {
+				if _, exists := merged[k]; !exists {
+					merged[k] = v
+				}
+			}
+		}
+		return g.goType(path+"[]", merged)
+	}
+
+	if len(maps) > 0 {
+		return "any"
+	}
+
+	types := make(map[string]bool)
+	for i, item := range list {
+		types[g.goType(fmt.Sprintf("%s[%d]", path, i), item)] = true
+	}
+	if len(types) == 1 {
+		for t := range types {
+			return t
+		}
+	}
+	if len(types) == 2 && types["int"] && types["float64"] {
+		return "float64"
+	}
+	return "any"
+}
+
+func (g *generator) structName(path string) string {
+	if name, ok := g.typeByPath[path]; ok {
+		return name
+	}
+	var sb strings.Builder
+	for _, seg := range strings.Split(path, ".") {
+		if strings.HasSuffix(seg, "[]") {
+			sb.WriteString(exportedName(strings.TrimSuffix(seg, "[]")))
+			sb.WriteString("Item")
+			continue
+		}
+		sb.WriteString(exportedName(seg))
+	}
+	name := sb.String()
+	for g.used[name] {
+		name += "Config"
+	}
+	g.used[name] = true
+	g.typeByPath[path] = name
+	return name
+}
+
+func structCompatible(m map[string]any) bool {
+	names := make(map[string]bool)
+	for k := range m {
+		name := exportedName(k)
+		if !isGoFieldName(name) || names[name] {
+			return false
+		}
+		names[name] = true
+	}
+	return true
+}
+
+func looksLikeDuration(s string) bool {
+	if s == "" || !strings.ContainsAny(s, "hmsuµn") {
+		return false
+	}
+	if s[0] != '-' && s[0] != '+' && (s[0] < '0' || s[0] > '9') {
+		return false
+	}
+	_, err := time.ParseDuration(s)
+	return err == nil
+}
+
+func exportedName(key string) string {
+	parts := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var sb strings.Builder
+	for _, part := range parts {
+		for _, word := range splitCamel(part) {
+			upper := strings.ToUpper(word)
+			if commonInitialisms[upper] {
+				sb.WriteString(upper)
+				continue
+			}
+			r, size := utf8.DecodeRuneInString(word)
+			sb.WriteRune(unicode.ToUpper(r))
+			sb.WriteString(word[size:])
+		}
+	}
+	return sb.String()
+}
+
+// isGoFieldName reports whether name, as built by exportedName, can be used
+// as an exported struct field.
+func isGoFieldName(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return name != "" && unicode.IsUpper(r)
+}
+
+func splitCamel(s string) []string {
+	var words []string
+	start := 0
+	runes := []rune(s)
+	for i := 1; i < len(runes); i++ {
+		if unicode.IsUpper(runes[i]) && !unicode.IsUpper(runes[i-1]) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	return append(words, string(runes[start:]))
+}
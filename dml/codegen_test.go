@@ -0,0 +1,122 @@
+package dml
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+)
+
+const codegenSource = `// Service name
+string name = "api";
+
+// HTTP server settings
+map server = {
+  "port": 8080,
+  "read_timeout": "15s",
+  "tls": {"enabled": true}
+};
+
+float ratio = 0.5;
+duration grace = "30s";
+list ports = [80, 443];
+list backends = [{"url": "http://a", "weight": 1}];
+`
+
+func TestGenerateGo_Struct(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse(codegenSource); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	src, err := GenerateGo(cfg, GenerateOptions{Package: "config", Source: "app.dml"})
+	if err != nil {
+		t.Fatalf("GenerateGo: %v", err)
+	}
+	out := string(src)
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "config_gen.go", src, 0); err != nil {
+		t.Fatalf("generated code does not parse: %v\n%s", err, out)
+	}
+
+	expected := []string{
+		"package config",
+		"// Code generated by dml gen go from app.dml; DO NOT EDIT.",
+		"// Service name\n\tName string `dml:\"name\"`",
+		"// HTTP server settings\n\tServer Server",
+		"Ratio float64",
+		"Grace time.Duration",
+		"Ports []int",
+		"Backends []BackendsItem",
+		"ReadTimeout time.Duration `dml:\"read_timeout\"`",
+		"TLS ServerTLS",
+		"URL string",
+		"func Load(path string) (*Config, error)",
+	}
+	normalized := strings.Join(strings.Fields(out), " ")
+	for _, want := range expected {
+		if !strings.Contains(normalized, strings.Join(strings.Fields(want), " ")) {
+			t.Errorf("generated code missing %q\n%s", want, out)
+		}
+	}
+}
+
+func TestGenerateGo_NoTimeImportWithoutDurations(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse(`string name = "api";`); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	src, err := GenerateGo(cfg, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateGo: %v", err)
+	}
+	if strings.Contains(string(src), `"time"`) {
+		t.Errorf("did not expect time import:\n%s", src)
+	}
+	if !strings.Contains(string(src), "package config") {
+		t.Errorf("expected default package name config:\n%s", src)
+	}
+}
+
+func TestExportedName(t *testing.T) {
+	tests := map[string]string{
+		"name":         "Name",
+		"read_timeout": "ReadTimeout",
+		"db_url":       "DBURL",
+		"api-key":      "APIKey",
+		"maxConns":     "MaxConns",
+		"émile":        "Émile",
+		"über_mode":    "ÜberMode",
+	}
+	for in, want := range tests {
+		if got := exportedName(in); got != want {
+			t.Errorf("exportedName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestGenerateGo_InvalidTopLevelKey(t *testing.T) {
+	cfg := New()
+	cfg.Set("1st", "x")
+
+	_, err := GenerateGo(cfg, GenerateOptions{})
+	if err == nil || !strings.Contains(err.Error(), `cannot generate field for key "1st"`) {
+		t.Errorf("expected a clear error for key 1st, got %v", err)
+	}
+}
+
+func TestGenerateGo_NestedKeysFallBackToMap(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse(`map limits = {"1st": 1, "2nd": 2};`); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	src, err := GenerateGo(cfg, GenerateOptions{})
+	if err != nil {
+		t.Fatalf("GenerateGo: %v", err)
+	}
+	if !strings.Contains(string(src), "map[string]any") {
+		t.Errorf("expected a map field for keys that are not identifiers:\n%s", src)
+	}
+}
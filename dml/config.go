@@ -3,11 +3,13 @@ package dml
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"reflect"
 	"sort"
 	"strings"
 	"sync"
+	"time"
 )
 
 type MapStyle int
@@ -64,6 +66,12 @@ type Config struct {
 	data        map[string]any
 	defaultKeys map[string]bool
 	mapStyle    MapStyle
+	decls       []Declaration
+	types       map[string]string
+	schema      map[string]string
+	locale      string
+	strict      bool
+	env         Env
 }
 
 func New() *Config {
@@ -71,6 +79,7 @@ func New() *Config {
 		data:        make(map[string]any),
 		defaultKeys: make(map[string]bool),
 		mapStyle:    MapStyleAuto,
+		types:       make(map[string]string),
 	}
 }
 
@@ -112,6 +121,13 @@ func (c *Config) SetMapStyle(style MapStyle) {
 	c.mapStyle = style
 }
 
+// SetStrict makes Parse reject a key declared twice, and a dotted key that
+// overwrites a value declared earlier, instead of letting the later
+// declaration win silently.
+func (c *Config) SetStrict(strict bool) {
+	c.strict = strict
+}
+
 func (c *Config) getEffectiveMapStyle() MapStyle {
 	if c.mapStyle != MapStyleAuto {
 		return c.mapStyle
@@ -119,7 +135,15 @@ func (c *Config) getEffectiveMapStyle() MapStyle {
 	return globalMapStyle
 }
 
+// Set stores value at key. Keys may use the path syntax described in path.go
+// to address list items, e.g. "servers[1].host"; such a set is skipped when
+// the list or index does not exist.
 func (c *Config) Set(key string, value any) {
+	if isPathExpr(key) {
+		c.SetPath(key, value)
+		return
+	}
+
 	keys := strings.Split(key, ".")
 	current := c.data
 
@@ -139,6 +163,10 @@ func (c *Config) Set(key string, value any) {
 }
 
 func (c *Config) Get(key string) (any, bool) {
+	if isPathExpr(key) {
+		return c.getPath(key)
+	}
+
 	keys := strings.Split(key, ".")
 	current := c.data
 
@@ -204,6 +232,21 @@ func (c *Config) GetNumber(key string) float64 {
 	return c.GetFloat(key)
 }
 
+func (c *Config) GetDuration(key string) time.Duration {
+	if val, exists := c.Get(key); exists {
+		switch v := val.(type) {
+		case time.Duration:
+			return v
+		case string:
+			d, _ := time.ParseDuration(v)
+			return d
+		case int:
+			return time.Duration(v)
+		}
+	}
+	return 0
+}
+
 func (c *Config) GetBool(key string) bool {
 	if val, exists := c.Get(key); exists {
 		if b, ok := val.(bool); ok {
@@ -248,6 +291,28 @@ func (c *Config) Keys() []string {
 	return keys
 }
 
+// Flatten returns every leaf value keyed by its full dotted path. Lists are
+// leaves; empty maps are kept so they are not lost.
+func (c *Config) Flatten() map[string]any {
+	out := make(map[string]any)
+	flattenInto(out, "", c.data)
+	return out
+}
+
+func flattenInto(out map[string]any, prefix string, data map[string]any) {
+	for k, v := range data {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		if nested, ok := v.(map[string]any); ok && len(nested) > 0 {
+			flattenInto(out, key, nested)
+			continue
+		}
+		out[key] = v
+	}
+}
+
 func (c *Config) MustString(key string) string {
 	val := c.GetString(key)
 	if val == "" && !c.Has(key) {
@@ -288,6 +353,8 @@ func (c *Config) ValidateRequiredTyped(rules map[string]string) error {
 			typeMatches = ok1 || ok2
 		case "bool":
 			_, typeMatches = val.(bool)
+		case "duration":
+			_, typeMatches = val.(time.Duration)
 		case "list":
 			_, typeMatches = val.([]any)
 		case "map":
@@ -311,6 +378,7 @@ func ApplyDefaults(filepath string, defaults map[string]any, policy DefaultPolic
 		data:        cfg,
 		defaultKeys: make(map[string]bool),
 		mapStyle:    MapStyleAuto,
+		types:       make(map[string]string),
 	}
 
 	if policy.SkipIfPresent && len(config.data) > 0 {
@@ -323,7 +391,22 @@ func ApplyDefaults(filepath string, defaults map[string]any, policy DefaultPolic
 		}
 	}
 
-	return config.SaveToFile(filepath)
+	if len(config.defaultKeys) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(config.defaultKeys))
+	for key := range config.defaultKeys {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	editor := Edit(filepath)
+	for _, key := range keys {
+		value, _ := config.Get(key)
+		editor.Set(key, value)
+	}
+	return editor.Save()
 }
 
 func (c *Config) applyDefault(key string, value any, policy DefaultPolicy) error {
@@ -354,10 +437,15 @@ func typesMatch(a, b any) bool {
 }
 
 func (c *Config) SaveToFile(filepath string) error {
-	content := c.Dump()
+	content, err := c.ToDML()
+	if err != nil {
+		return err
+	}
 	return os.WriteFile(filepath, []byte(content), 0644)
 }
 
+// Dump renders the config as DML. It does not check that strings can be
+// written; use ToDML when they may contain double quotes or newlines.
 func (c *Config) Dump() string {
 	var builder strings.Builder
 	style := c.getEffectiveMapStyle()
@@ -372,6 +460,45 @@ func (c *Config) Dump() string {
 	return builder.String()
 }
 
+// ToDML renders the config like Dump, but returns an error instead of text
+// that would not parse back. DML strings have no escapes, so strings and map
+// keys cannot contain a double quote or a newline.
+func (c *Config) ToDML() (string, error) {
+	if err := checkDumpable("", c.data); err != nil {
+		return "", err
+	}
+	return c.Dump(), nil
+}
+
+func checkDumpable(key string, value any) error {
+	switch v := value.(type) {
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return fmt.Errorf("key '%s': float %v cannot be written as DML: it is not a finite number", key, v)
+		}
+	case string:
+		if strings.ContainsAny(v, "\"\n") {
+			return fmt.Errorf("key '%s': string %q cannot be written as DML: it contains a double quote or newline", key, v)
+		}
+	case map[string]any:
+		for _, k := range sortedMapKeys(v) {
+			if strings.ContainsAny(k, "\"\n") {
+				return fmt.Errorf("key '%s': map key %q cannot be written as DML: it contains a double quote or newline", key, k)
+			}
+			if err := checkDumpable(joinKey(key, k), v[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for i, item := range v {
+			if err := checkDumpable(fmt.Sprintf("%s[%d]", key, i), item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (c *Config) dumpData(builder *strings.Builder, data map[string]any, prefix string, style MapStyle) {
 	keys := make([]string, 0, len(data))
 	for k := range data {
@@ -400,70 +527,63 @@ func (c *Config) dumpValue(builder *strings.Builder, key string, value any, styl
 }
 
 func (c *Config) dumpMap(builder *strings.Builder, key string, m map[string]any, style MapStyle) {
+	if len(m) == 0 {
+		builder.WriteString(fmt.Sprintf("map %s = {};\n\n", key))
+		return
+	}
+
 	if style == MapStyleJSON {
-		builder.WriteString(fmt.Sprintf("map %s = {\n", key))
-		keys := c.sortedKeys(m)
-		for i, k := range keys {
-			builder.WriteString(fmt.Sprintf("  \"%s\": ", k))
-			c.dumpInlineValue(builder, m[k])
-			if i < len(keys)-1 {
-				builder.WriteString(",")
-			}
-			builder.WriteString("\n")
-		}
-		builder.WriteString("};\n\n")
+		c.dumpMapLiteral(builder, key, m)
 	} else if style == MapStyleFlat {
 		c.dumpData(builder, m, key, style)
 	} else {
 		if len(m) > 3 || c.hasNestedStructures(m) {
-			builder.WriteString(fmt.Sprintf("map %s = {\n", key))
-			keys := c.sortedKeys(m)
-			for i, k := range keys {
-				builder.WriteString(fmt.Sprintf("  \"%s\": ", k))
-				c.dumpInlineValue(builder, m[k])
-				if i < len(keys)-1 {
-					builder.WriteString(",")
-				}
-				builder.WriteString("\n")
-			}
-			builder.WriteString("};\n\n")
+			c.dumpMapLiteral(builder, key, m)
 		} else {
 			c.dumpData(builder, m, key, style)
 		}
 	}
 }
 
-func (c *Config) dumpArray(builder *strings.Builder, key string, arr []any) {
-	builder.WriteString(fmt.Sprintf("list %s = [", key))
-	for i, v := range arr {
-		if i > 0 {
-			builder.WriteString(", ")
-		}
-		switch val := v.(type) {
-		case string:
-			builder.WriteString(fmt.Sprintf("\"%s\"", val))
-		default:
-			builder.WriteString(fmt.Sprintf("%v", val))
+func (c *Config) dumpMapLiteral(builder *strings.Builder, key string, m map[string]any) {
+	builder.WriteString(fmt.Sprintf("map %s = {\n", key))
+	keys := c.sortedKeys(m)
+	for i, k := range keys {
+		builder.WriteString(fmt.Sprintf("  \"%s\": ", k))
+		c.dumpInlineValue(builder, m[k])
+		if i < len(keys)-1 {
+			builder.WriteString(",")
 		}
+		builder.WriteString("\n")
 	}
-	builder.WriteString("];\n\n")
+	builder.WriteString("};\n\n")
+}
+
+func (c *Config) dumpArray(builder *strings.Builder, key string, arr []any) {
+	builder.WriteString(fmt.Sprintf("%s %s = ", c.dumpTypeName(key, arr), key))
+	c.dumpInlineValue(builder, arr)
+	builder.WriteString(";\n\n")
 }
 
 func (c *Config) dumpScalar(builder *strings.Builder, key string, value any, style MapStyle) {
-	var typeName string
-	switch value.(type) {
-	case string:
-		typeName = "string"
-		builder.WriteString(fmt.Sprintf("%s %s = \"%v\";\n", typeName, key, value))
-	case float64, int:
-		typeName = "number"
-		builder.WriteString(fmt.Sprintf("%s %s = %v;\n", typeName, key, value))
-	case bool:
-		typeName = "boolean"
-		builder.WriteString(fmt.Sprintf("%s %s = %v;\n", typeName, key, value))
-	default:
-		builder.WriteString(fmt.Sprintf("string %s = \"%v\";\n", key, value))
+	typeName := c.dumpTypeName(key, value)
+	builder.WriteString(fmt.Sprintf("%s %s = ", typeName, key))
+	if typeName == "string" {
+		builder.WriteString(fmt.Sprintf("\"%v\"", value))
+	} else {
+		c.dumpInlineValue(builder, value)
 	}
+	builder.WriteString(";\n")
+}
+
+// dumpTypeName returns the declared type of key when it still describes value,
+// so aliases such as number or boolean survive a Dump/Parse round trip.
+func (c *Config) dumpTypeName(key string, value any) string {
+	actual := dmlTypeOf(value)
+	if declared, ok := c.types[key]; ok && holdsType(declared, value) {
+		return declared
+	}
+	return actual
 }
 
 func (c *Config) dumpInlineValue(builder *strings.Builder, value any) {
@@ -473,9 +593,11 @@ func (c *Config) dumpInlineValue(builder *strings.Builder, value any) {
 	case int, int64:
 		builder.WriteString(fmt.Sprintf("%d", v))
 	case float64:
-		builder.WriteString(fmt.Sprintf("%g", v))
+		builder.WriteString(formatDMLFloat(v))
 	case bool:
 		builder.WriteString(fmt.Sprintf("%t", v))
+	case time.Duration:
+		builder.WriteString(fmt.Sprintf("\"%s\"", v))
 	case map[string]any:
 		builder.WriteString("{ ")
 		keys := c.sortedKeys(v)
@@ -595,8 +717,10 @@ func Load(filepath string) (map[string]any, error) {
 	return cfg.data, nil
 }
 
+// ToJSON renders the data as indented JSON. Durations are written as text,
+// such as "30s", which LoadJSON reads back for duration keys.
 func (c *Config) ToJSON() (string, error) {
-	jsonBytes, err := json.MarshalIndent(c.data, "", "  ")
+	jsonBytes, err := json.MarshalIndent(durationsAsText(c.data), "", "  ")
 	if err != nil {
 		return "", err
 	}
@@ -604,5 +728,5 @@ func (c *Config) ToJSON() (string, error) {
 }
 
 func (c *Config) FromJSON(jsonStr string) error {
-	return json.Unmarshal([]byte(jsonStr), &c.data)
+	return c.LoadJSON([]byte(jsonStr), JSONReplace)
 }
@@ -0,0 +1,521 @@
+package dml
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConvertWarning describes a value that could not be converted losslessly
+// between DML and another format.
+type ConvertWarning struct {
+	Key     string
+	Message string
+}
+
+func (w ConvertWarning) String() string {
+	if w.Key == "" {
+		return w.Message
+	}
+	return fmt.Sprintf("%s: %s", w.Key, w.Message)
+}
+
+type converter struct {
+	warnings []ConvertWarning
+}
+
+func (cv *converter) warn(key, format string, args ...any) {
+	cv.warnings = append(cv.warnings, ConvertWarning{Key: key, Message: fmt.Sprintf(format, args...)})
+}
+
+// FromYAML replaces the config with a YAML document. Values YAML can hold
+// but DML cannot, such as timestamps or custom tags, are kept as strings and
+// reported as warnings.
+func (c *Config) FromYAML(src string) ([]ConvertWarning, error) {
+	cv := &converter{}
+	dec := yaml.NewDecoder(strings.NewReader(src))
+
+	var doc yaml.Node
+	if err := dec.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			c.data = make(map[string]any)
+			c.inferTypes()
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var extra yaml.Node
+	if err := dec.Decode(&extra); err == nil {
+		cv.warn("", "only the first YAML document was imported")
+	}
+
+	root := &doc
+	if root.Kind == yaml.DocumentNode && len(root.Content) > 0 {
+		root = root.Content[0]
+	}
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("top-level YAML value must be a mapping")
+	}
+
+	data := make(map[string]any)
+	cv.yamlMapping("", root, data)
+	c.data = data
+	c.inferTypes()
+	return cv.warnings, nil
+}
+
+func (cv *converter) yamlMapping(path string, n *yaml.Node, out map[string]any) {
+	if n.Anchor != "" {
+		cv.warn(path, "anchor &%s dropped", n.Anchor)
+	}
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		keyNode, valNode := n.Content[i], n.Content[i+1]
+
+		if keyNode.ShortTag() == "!!merge" {
+			cv.yamlMerge(path, valNode, out)
+			continue
+		}
+
+		key := keyNode.Value
+		if keyNode.Kind != yaml.ScalarNode {
+			cv.warn(path, "complex mapping key skipped")
+			continue
+		}
+		if keyNode.ShortTag() != "!!str" {
+			cv.warn(joinKey(path, key), "non-string key %s converted to string", keyNode.ShortTag())
+		}
+
+		if val, ok := cv.yamlValue(joinKey(path, key), valNode); ok {
+			out[key] = val
+		}
+	}
+}
+
+func (cv *converter) yamlMerge(path string, n *yaml.Node, out map[string]any) {
+	sources := []*yaml.Node{n}
+	if n.Kind == yaml.SequenceNode {
+		sources = n.Content
+	}
+
+	merged := make(map[string]any)
+	for _, src := range sources {
+		if src.Kind == yaml.AliasNode {
+			src = src.Alias
+		}
+		if src.Kind != yaml.MappingNode {
+			cv.warn(path, "merge key with non-mapping value skipped")
+			continue
+		}
+		m := make(map[string]any)
+		cv.yamlMapping(path, src, m)
+		for k, v := range m {
+			if _, exists := merged[k]; !exists {
+				merged[k] = v
+			}
+		}
+	}
+
+	cv.warn(path, "merge key << expanded into explicit entries")
+	for k, v := range merged {
+		if _, exists := out[k]; !exists {
+			out[k] = v
+		}
+	}
+}
+
+func (cv *converter) yamlValue(path string, n *yaml.Node) (any, bool) {
+	switch n.Kind {
+	case yaml.AliasNode:
+		cv.warn(path, "alias *%s expanded to a copy of its anchor", n.Value)
+		target := *n.Alias
+		target.Anchor = ""
+		return cv.yamlValue(path, &target)
+	case yaml.MappingNode:
+		m := make(map[string]any)
+		cv.yamlMapping(path, n, m)
+		return m, true
+	case yaml.SequenceNode:
+		if n.Anchor != "" {
+			cv.warn(path, "anchor &%s dropped", n.Anchor)
+		}
+		list := make([]any, 0, len(n.Content))
+		for i, item := range n.Content {
+			if val, ok := cv.yamlValue(fmt.Sprintf("%s[%d]", path, i), item); ok {
+				list = append(list, val)
+			}
+		}
+		return list, true
+	case yaml.ScalarNode:
+		if n.Anchor != "" {
+			cv.warn(path, "anchor &%s dropped", n.Anchor)
+		}
+		return cv.yamlScalar(path, n)
+	}
+	cv.warn(path, "unsupported YAML node skipped")
+	return nil, false
+}
+
+func (cv *converter) yamlScalar(path string, n *yaml.Node) (any, bool) {
+	switch n.ShortTag() {
+	case "!!str":
+		return n.Value, true
+	case "!!int":
+		var i int
+		if err := n.Decode(&i); err != nil {
+			cv.warn(path, "integer %s out of range, kept as string", n.Value)
+			return n.Value, true
+		}
+		return i, true
+	case "!!float":
+		var f float64
+		if err := n.Decode(&f); err != nil {
+			return n.Value, true
+		}
+		return f, true
+	case "!!bool":
+		var b bool
+		if err := n.Decode(&b); err != nil {
+			return n.Value, true
+		}
+		return b, true
+	case "!!null":
+		cv.warn(path, "null has no DML equivalent, key dropped")
+		return nil, false
+	case "!!timestamp":
+		cv.warn(path, "timestamp converted to string")
+		return n.Value, true
+	case "!!binary":
+		cv.warn(path, "binary value kept as base64 string")
+		return n.Value, true
+	default:
+		cv.warn(path, "custom tag %s dropped, value kept as string", n.Tag)
+		return n.Value, true
+	}
+}
+
+// ToYAML renders the config as YAML. Durations are written as strings, with
+// a warning.
+func (c *Config) ToYAML() (string, []ConvertWarning, error) {
+	cv := &converter{}
+	root := cv.yamlNode("", c.data)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(root); err != nil {
+		return "", nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return "", nil, err
+	}
+	return buf.String(), cv.warnings, nil
+}
+
+func (cv *converter) yamlNode(path string, value any) *yaml.Node {
+	switch v := value.(type) {
+	case map[string]any:
+		n := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, k := range sortedMapKeys(v) {
+			n.Content = append(n.Content,
+				&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: k},
+				cv.yamlNode(joinKey(path, k), v[k]))
+		}
+		return n
+	case []any:
+		n := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for i, item := range v {
+			n.Content = append(n.Content, cv.yamlNode(fmt.Sprintf("%s[%d]", path, i), item))
+		}
+		return n
+	case string:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	case int:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!int", Value: strconv.Itoa(v)}
+	case float64:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!float", Value: formatYAMLFloat(v)}
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case time.Duration:
+		cv.warn(path, "duration written as string")
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v.String()}
+	}
+	cv.warn(path, "value of type %T written as string", value)
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: fmt.Sprintf("%v", value)}
+}
+
+func formatYAMLFloat(f float64) string {
+	switch {
+	case math.IsInf(f, 1):
+		return ".inf"
+	case math.IsInf(f, -1):
+		return "-.inf"
+	case math.IsNaN(f):
+		return ".nan"
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return s
+}
+
+// FromTOML replaces the config with a TOML document. Dates and times become
+// strings, with a warning for each.
+func (c *Config) FromTOML(src string) ([]ConvertWarning, error) {
+	var raw map[string]any
+	if _, err := toml.Decode(src, &raw); err != nil {
+		return nil, err
+	}
+
+	cv := &converter{}
+	data := make(map[string]any, len(raw))
+	for k, v := range raw {
+		data[k] = cv.tomlValue(k, v)
+	}
+	c.data = data
+	c.inferTypes()
+	return cv.warnings, nil
+}
+
+func (cv *converter) tomlValue(path string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[k] = cv.tomlValue(joinKey(path, k), item)
+		}
+		return m
+	case []map[string]any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = cv.tomlValue(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+		return list
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = cv.tomlValue(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+		return list
+	case int64:
+		if v > math.MaxInt || v < math.MinInt {
+			cv.warn(path, "integer %d out of range, kept as float", v)
+			return float64(v)
+		}
+		return int(v)
+	case time.Time:
+		cv.warn(path, "TOML datetime converted to string")
+		switch v.Location().String() {
+		case "datetime-local":
+			return v.Format("2006-01-02T15:04:05.999999999")
+		case "date-local":
+			return v.Format("2006-01-02")
+		case "time-local":
+			return v.Format("15:04:05.999999999")
+		}
+		return v.Format(time.RFC3339Nano)
+	}
+	return value
+}
+
+// ToTOML renders the config as TOML. Durations are written as strings, with
+// a warning.
+func (c *Config) ToTOML() (string, []ConvertWarning, error) {
+	cv := &converter{}
+	data := cv.tomlPrepare("", c.data).(map[string]any)
+
+	var buf bytes.Buffer
+	enc := toml.NewEncoder(&buf)
+	enc.Indent = ""
+	if err := enc.Encode(data); err != nil {
+		return "", nil, err
+	}
+	return buf.String(), cv.warnings, nil
+}
+
+func (cv *converter) tomlPrepare(path string, value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			m[k] = cv.tomlPrepare(joinKey(path, k), item)
+		}
+		return m
+	case []any:
+		list := make([]any, len(v))
+		for i, item := range v {
+			list[i] = cv.tomlPrepare(fmt.Sprintf("%s[%d]", path, i), item)
+		}
+		return list
+	case time.Duration:
+		cv.warn(path, "duration written as string")
+		return v.String()
+	}
+	return value
+}
+
+// FromINI replaces the config with an INI file. Sections, which may be
+// dotted, become nested maps; values are read as bools, numbers or strings.
+func (c *Config) FromINI(src string) ([]ConvertWarning, error) {
+	cv := &converter{}
+	data := make(map[string]any)
+	section := data
+	sectionName := ""
+
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("invalid INI section at line %d: %s", lineNum, line)
+			}
+			sectionName = strings.TrimSpace(line[1 : len(line)-1])
+			section = data
+			for _, part := range strings.Split(sectionName, ".") {
+				next, ok := section[part].(map[string]any)
+				if !ok {
+					if _, exists := section[part]; exists {
+						cv.warn(sectionName, "section replaces scalar value %q", part)
+					}
+					next = make(map[string]any)
+					section[part] = next
+				}
+				section = next
+			}
+			continue
+		}
+
+		idx := strings.IndexAny(line, "=:")
+		if idx < 0 {
+			return nil, fmt.Errorf("invalid INI format at line %d: %s", lineNum, line)
+		}
+
+		key := strings.TrimSpace(line[:idx])
+		value := strings.TrimSpace(line[idx+1:])
+		if _, exists := section[key]; exists {
+			cv.warn(joinKey(sectionName, key), "duplicate key, last value wins")
+		}
+		section[key] = parseINIValue(value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	c.data = data
+	c.inferTypes()
+	return cv.warnings, nil
+}
+
+func parseINIValue(value string) any {
+	if len(value) >= 2 {
+		if (value[0] == '"' && value[len(value)-1] == '"') || (value[0] == '\'' && value[len(value)-1] == '\'') {
+			if unquoted, err := strconv.Unquote(`"` + value[1:len(value)-1] + `"`); err == nil {
+				return unquoted
+			}
+			return value[1 : len(value)-1]
+		}
+	}
+
+	switch strings.ToLower(value) {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if i, err := strconv.Atoi(value); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(value, 64); err == nil {
+		return f
+	}
+	return value
+}
+
+// ToINI renders the config as INI, one section per nested map. Lists are
+// written as comma-separated strings and durations as strings, with
+// warnings.
+func (c *Config) ToINI() (string, []ConvertWarning, error) {
+	cv := &converter{}
+	var buf strings.Builder
+
+	cv.iniSection(&buf, "", c.data)
+	return strings.TrimLeft(buf.String(), "\n"), cv.warnings, nil
+}
+
+func (cv *converter) iniSection(buf *strings.Builder, name string, m map[string]any) {
+	keys := sortedMapKeys(m)
+
+	if name != "" {
+		fmt.Fprintf(buf, "\n[%s]\n", name)
+	}
+	for _, k := range keys {
+		if _, isMap := m[k].(map[string]any); isMap {
+			continue
+		}
+		fmt.Fprintf(buf, "%s = %s\n", k, cv.iniValue(joinKey(name, k), m[k]))
+	}
+
+	for _, k := range keys {
+		if nested, isMap := m[k].(map[string]any); isMap {
+			cv.iniSection(buf, joinKey(name, k), nested)
+		}
+	}
+}
+
+func (cv *converter) iniValue(path string, value any) string {
+	switch v := value.(type) {
+	case string:
+		if _, isString := parseINIValue(v).(string); !isString || v != strings.TrimSpace(v) || strings.ContainsAny(v, "\"';#") {
+			return strconv.Quote(v)
+		}
+		return v
+	case float64:
+		return formatDMLFloat(v)
+	case time.Duration:
+		cv.warn(path, "duration written as string")
+		return v.String()
+	case []any:
+		cv.warn(path, "INI has no lists, written as comma-separated string")
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprintf("%v", item)
+		}
+		return strconv.Quote(strings.Join(parts, ","))
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+func sortedMapKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func formatDMLFloat(f float64) string {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".eEIN") {
+		s += ".0"
+	}
+	return s
+}
@@ -0,0 +1,247 @@
+package dml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func hasWarning(warnings []ConvertWarning, key, substr string) bool {
+	for _, w := range warnings {
+		if w.Key == key && strings.Contains(w.Message, substr) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFromYAML_NativeTypes(t *testing.T) {
+	src := `
+name: api
+port: 8080
+ratio: 0.5
+debug: true
+hosts: [a, b]
+server:
+  tls:
+    enabled: false
+`
+	cfg := New()
+	warnings, err := cfg.FromYAML(src)
+	if err != nil {
+		t.Fatalf("FromYAML: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	if v, _ := cfg.Get("port"); v != 8080 {
+		t.Errorf("expected port int 8080, got %#v", v)
+	}
+	if v, _ := cfg.Get("ratio"); v != 0.5 {
+		t.Errorf("expected ratio 0.5, got %#v", v)
+	}
+	if cfg.GetBool("server.tls.enabled") {
+		t.Error("expected server.tls.enabled=false")
+	}
+	if !reflect.DeepEqual(cfg.GetList("hosts"), []any{"a", "b"}) {
+		t.Errorf("unexpected hosts: %v", cfg.GetList("hosts"))
+	}
+}
+
+func TestFromYAML_Warnings(t *testing.T) {
+	src := `
+base: &base
+  timeout: 30
+prod:
+  <<: *base
+  host: prod
+copy: *base
+missing: ~
+created: 2024-01-02
+`
+	cfg := New()
+	warnings, err := cfg.FromYAML(src)
+	if err != nil {
+		t.Fatalf("FromYAML: %v", err)
+	}
+
+	if cfg.GetInt("prod.timeout") != 30 || cfg.GetInt("copy.timeout") != 30 {
+		t.Errorf("expected merged/aliased timeout=30, got %v / %v", cfg.GetInt("prod.timeout"), cfg.GetInt("copy.timeout"))
+	}
+	if cfg.Has("missing") {
+		t.Error("expected null key to be dropped")
+	}
+
+	for _, want := range []struct{ key, msg string }{
+		{"base", "anchor &base dropped"},
+		{"prod", "merge key"},
+		{"copy", "alias *base"},
+		{"missing", "null"},
+		{"created", "timestamp"},
+	} {
+		if !hasWarning(warnings, want.key, want.msg) {
+			t.Errorf("expected warning %q for %s, got %v", want.msg, want.key, warnings)
+		}
+	}
+}
+
+func TestYAML_RoundTrip(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse(`
+string name = "api";
+int port = 8080;
+float ratio = 2.0;
+bool debug = true;
+list hosts = ["a", "b"];
+map server = {"host": "localhost", "port": 9090};
+`); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	out, warnings, err := cfg.ToYAML()
+	if err != nil {
+		t.Fatalf("ToYAML: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+
+	back := New()
+	if _, err := back.FromYAML(out); err != nil {
+		t.Fatalf("FromYAML: %v\n%s", err, out)
+	}
+	if !reflect.DeepEqual(cfg.data, back.data) {
+		t.Errorf("round trip mismatch:\n%#v\n%#v", cfg.data, back.data)
+	}
+}
+
+func TestFromTOML(t *testing.T) {
+	src := `
+name = "api"
+port = 8080
+ratio = 0.5
+released = 1979-05-27T07:32:00Z
+day = 1979-05-27
+
+[server]
+host = "localhost"
+
+[[backends]]
+url = "http://a"
+`
+	cfg := New()
+	warnings, err := cfg.FromTOML(src)
+	if err != nil {
+		t.Fatalf("FromTOML: %v", err)
+	}
+
+	if v, _ := cfg.Get("port"); v != 8080 {
+		t.Errorf("expected port int 8080, got %#v", v)
+	}
+	if cfg.GetString("server.host") != "localhost" {
+		t.Errorf("expected server.host=localhost, got %q", cfg.GetString("server.host"))
+	}
+	if cfg.GetString("released") != "1979-05-27T07:32:00Z" {
+		t.Errorf("unexpected released: %q", cfg.GetString("released"))
+	}
+	if cfg.GetString("day") != "1979-05-27" {
+		t.Errorf("unexpected day: %q", cfg.GetString("day"))
+	}
+	backends := cfg.GetList("backends")
+	if len(backends) != 1 {
+		t.Fatalf("expected 1 backend, got %v", backends)
+	}
+	if !hasWarning(warnings, "released", "datetime") || !hasWarning(warnings, "day", "datetime") {
+		t.Errorf("expected datetime warnings, got %v", warnings)
+	}
+}
+
+func TestTOML_RoundTrip(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse(`
+string name = "api";
+int port = 8080;
+float ratio = 0.25;
+list ports = [80, 443];
+map server = {"host": "localhost", "tls": {"enabled": true}};
+`); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	out, _, err := cfg.ToTOML()
+	if err != nil {
+		t.Fatalf("ToTOML: %v", err)
+	}
+
+	back := New()
+	if _, err := back.FromTOML(out); err != nil {
+		t.Fatalf("FromTOML: %v\n%s", err, out)
+	}
+	if !reflect.DeepEqual(cfg.data, back.data) {
+		t.Errorf("round trip mismatch:\n%#v\n%#v", cfg.data, back.data)
+	}
+}
+
+func TestINI_RoundTrip(t *testing.T) {
+	src := `; global settings
+name = api
+port = 8080
+
+[server]
+host = localhost
+debug = true
+version = "1.0"
+
+[server.tls]
+enabled = false
+`
+	cfg := New()
+	warnings, err := cfg.FromINI(src)
+	if err != nil {
+		t.Fatalf("FromINI: %v", err)
+	}
+	if len(warnings) != 0 {
+		t.Errorf("unexpected warnings: %v", warnings)
+	}
+	if v, _ := cfg.Get("port"); v != 8080 {
+		t.Errorf("expected port int 8080, got %#v", v)
+	}
+	if v, _ := cfg.Get("server.version"); v != "1.0" {
+		t.Errorf("expected quoted version to stay a string, got %#v", v)
+	}
+
+	out, _, err := cfg.ToINI()
+	if err != nil {
+		t.Fatalf("ToINI: %v", err)
+	}
+	back := New()
+	if _, err := back.FromINI(out); err != nil {
+		t.Fatalf("FromINI: %v\n%s", err, out)
+	}
+	if !reflect.DeepEqual(cfg.data, back.data) {
+		t.Errorf("round trip mismatch:\n%#v\n%#v\n%s", cfg.data, back.data, out)
+	}
+}
+
+func TestToINI_ListWarning(t *testing.T) {
+	cfg := New()
+	cfg.Set("hosts", []any{"a", "b"})
+
+	out, warnings, err := cfg.ToINI()
+	if err != nil {
+		t.Fatalf("ToINI: %v", err)
+	}
+	if !strings.Contains(out, `hosts = "a,b"`) {
+		t.Errorf("unexpected output: %s", out)
+	}
+	if !hasWarning(warnings, "hosts", "no lists") {
+		t.Errorf("expected list warning, got %v", warnings)
+	}
+}
+
+func TestFromINI_InvalidLine(t *testing.T) {
+	if _, err := New().FromINI("[server]\nnot a pair\n"); err == nil {
+		t.Fatal("expected error for line without '='")
+	}
+}
@@ -0,0 +1,286 @@
+package dml
+
+import (
+	"sort"
+	"strings"
+)
+
+// The concrete syntax tree keeps byte offsets into the original source so that
+// tools can edit or inspect a file without regenerating it from Dump.
+
+type cstKind int
+
+const (
+	cstBlank cstKind = iota
+	cstComment
+	cstDirective
+	cstDecl
+)
+
+type span struct {
+	start int
+	end   int
+}
+
+func (s span) valid() bool {
+	return s.end > s.start
+}
+
+type cstNode struct {
+	kind cstKind
+	// start and end cover whole source lines, including the trailing newline.
+	start   int
+	end     int
+	line    int
+	endLine int
+
+	// Declarations only.
+	typ      span
+	name     span
+	eq       int
+	value    span
+	semi     int
+	unclosed bool
+}
+
+type cstEntry struct {
+	start int
+	end   int
+	key   span
+	value span
+	name  string
+	comma int
+}
+
+type cstFile struct {
+	src        string
+	nodes      []*cstNode
+	lineStarts []int
+}
+
+func scanCST(src string) *cstFile {
+	f := &cstFile{src: src, lineStarts: []int{0}}
+	for i := 0; i < len(src); i++ {
+		if src[i] == '\n' {
+			f.lineStarts = append(f.lineStarts, i+1)
+		}
+	}
+
+	lineCount := len(f.lineStarts)
+	if strings.HasSuffix(src, "\n") {
+		lineCount--
+	}
+
+	var open *cstNode
+	for i := 0; i < lineCount; i++ {
+		start, end := f.lineBounds(i)
+		trimmed := strings.TrimSpace(src[start:end])
+
+		if open != nil {
+			open.end = f.lineEnd(i)
+			open.endLine = i + 1
+			if strings.HasSuffix(trimmed, ";") {
+				f.finishDecl(open)
+				open = nil
+			}
+			continue
+		}
+
+		node := &cstNode{start: start, end: f.lineEnd(i), line: i + 1, endLine: i + 1}
+		switch {
+		case trimmed == "":
+			node.kind = cstBlank
+		case strings.HasPrefix(trimmed, "//"):
+			node.kind = cstComment
+		case strings.HasPrefix(trimmed, "@"):
+			node.kind = cstDirective
+		default:
+			node.kind = cstDecl
+			if strings.ContainsAny(trimmed, "{[") && !strings.HasSuffix(trimmed, ";") {
+				open = node
+			} else {
+				f.finishDecl(node)
+			}
+		}
+		f.nodes = append(f.nodes, node)
+	}
+
+	if open != nil {
+		open.unclosed = true
+		f.finishDecl(open)
+	}
+	return f
+}
+
+func (f *cstFile) lineBounds(i int) (int, int) {
+	start := f.lineStarts[i]
+	end := len(f.src)
+	if i+1 < len(f.lineStarts) {
+		end = f.lineStarts[i+1] - 1
+	}
+	if end > start && f.src[end-1] == '\r' {
+		end--
+	}
+	return start, end
+}
+
+func (f *cstFile) lineEnd(i int) int {
+	if i+1 < len(f.lineStarts) {
+		return f.lineStarts[i+1]
+	}
+	return len(f.src)
+}
+
+// position converts a byte offset into a 1-based line and column.
+func (f *cstFile) position(offset int) (int, int) {
+	line := sort.Search(len(f.lineStarts), func(i int) bool { return f.lineStarts[i] > offset }) - 1
+	return line + 1, offset - f.lineStarts[line] + 1
+}
+
+func (f *cstFile) text(s span) string {
+	return f.src[s.start:s.end]
+}
+
+func (f *cstFile) finishDecl(n *cstNode) {
+	body := trimSpan(f.src, span{n.start, n.end})
+	n.semi = -1
+	if body.valid() && f.src[body.end-1] == ';' {
+		n.semi = body.end - 1
+		body.end--
+	}
+
+	n.eq = strings.IndexByte(f.src[body.start:body.end], '=')
+	if n.eq < 0 {
+		return
+	}
+	n.eq += body.start
+
+	decl := f.src[body.start:n.eq]
+	var fields []span
+	inField := false
+	for i := 0; i < len(decl); i++ {
+		space := decl[i] == ' ' || decl[i] == '\t'
+		if !space && !inField {
+			fields = append(fields, span{body.start + i, body.start + i})
+			inField = true
+		}
+		if space {
+			inField = false
+		}
+		if inField {
+			fields[len(fields)-1].end = body.start + i + 1
+		}
+	}
+	if len(fields) == 2 {
+		n.typ, n.name = fields[0], fields[1]
+	} else if len(fields) == 1 {
+		n.name = fields[0]
+	}
+
+	n.value = trimSpan(f.src, span{n.eq + 1, body.end})
+}
+
+func trimSpan(src string, s span) span {
+	for s.start < s.end && isSpace(src[s.start]) {
+		s.start++
+	}
+	for s.end > s.start && isSpace(src[s.end-1]) {
+		s.end--
+	}
+	return s
+}
+
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r'
+}
+
+func (n *cstNode) declName(f *cstFile) string {
+	return f.text(n.name)
+}
+
+func (n *cstNode) declType(f *cstFile) string {
+	return f.text(n.typ)
+}
+
+// entries splits a `{...}` or `[...]` literal at s into its top-level items.
+// For map literals each entry also carries its key.
+func (f *cstFile) entries(s span) []cstEntry {
+	if s.end-s.start < 2 {
+		return nil
+	}
+	open := f.src[s.start]
+	if (open != '{' || f.src[s.end-1] != '}') && (open != '[' || f.src[s.end-1] != ']') {
+		return nil
+	}
+
+	var result []cstEntry
+	depth := 0
+	inQuotes := false
+	pieceStart := s.start + 1
+
+	emit := func(end, comma int) {
+		piece := trimSpan(f.src, span{pieceStart, end})
+		if !piece.valid() {
+			return
+		}
+		e := cstEntry{start: piece.start, end: piece.end, value: piece, comma: comma}
+		if open == '{' {
+			if colon := f.topLevelIndex(piece, ':'); colon >= 0 {
+				e.key = trimSpan(f.src, span{piece.start, colon})
+				e.value = trimSpan(f.src, span{colon + 1, piece.end})
+				e.name = strings.Trim(f.text(e.key), `"`)
+			}
+		}
+		result = append(result, e)
+	}
+
+	for i := s.start + 1; i < s.end-1; i++ {
+		ch := f.src[i]
+		if ch == '"' {
+			inQuotes = !inQuotes
+			continue
+		}
+		if inQuotes {
+			continue
+		}
+		switch ch {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				emit(i, i)
+				pieceStart = i + 1
+			}
+		}
+	}
+	emit(s.end-1, -1)
+	return result
+}
+
+func (f *cstFile) topLevelIndex(s span, target byte) int {
+	depth := 0
+	inQuotes := false
+	for i := s.start; i < s.end; i++ {
+		ch := f.src[i]
+		if ch == '"' {
+			inQuotes = !inQuotes
+			continue
+		}
+		if inQuotes {
+			continue
+		}
+		switch ch {
+		case '{', '[':
+			depth++
+		case '}', ']':
+			depth--
+		default:
+			if ch == target && depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
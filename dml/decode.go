@@ -0,0 +1,206 @@
+package dml
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Decode copies the config into the struct pointed to by v. Fields are matched
+// by their `dml:"name"` tag, falling back to a case-insensitive field name match.
+func (c *Config) Decode(v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return fmt.Errorf("decode target must be a non-nil pointer, got %T", v)
+	}
+	return decodeValue("", c.data, rv.Elem())
+}
+
+func decodeValue(key string, src any, dst reflect.Value) error {
+	if dst.Type() == durationType {
+		d, err := toDuration(src)
+		if err != nil {
+			return fmt.Errorf("key '%s': %w", key, err)
+		}
+		dst.SetInt(int64(d))
+		return nil
+	}
+
+	switch dst.Kind() {
+	case reflect.Interface:
+		if src != nil {
+			dst.Set(reflect.ValueOf(src))
+		}
+		return nil
+	case reflect.Ptr:
+		if dst.IsNil() {
+			dst.Set(reflect.New(dst.Type().Elem()))
+		}
+		return decodeValue(key, src, dst.Elem())
+	case reflect.String:
+		switch v := src.(type) {
+		case string:
+			dst.SetString(v)
+		case time.Duration:
+			dst.SetString(v.String())
+		default:
+			dst.SetString(fmt.Sprintf("%v", v))
+		}
+		return nil
+	case reflect.Bool:
+		switch v := src.(type) {
+		case bool:
+			dst.SetBool(v)
+			return nil
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				dst.SetBool(b)
+				return nil
+			}
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		switch v := src.(type) {
+		case int:
+			dst.SetInt(int64(v))
+			return nil
+		case int64:
+			dst.SetInt(v)
+			return nil
+		case float64:
+			if v == float64(int64(v)) {
+				dst.SetInt(int64(v))
+				return nil
+			}
+		case string:
+			if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+				dst.SetInt(i)
+				return nil
+			}
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		switch v := src.(type) {
+		case int:
+			if v >= 0 {
+				dst.SetUint(uint64(v))
+				return nil
+			}
+		case string:
+			if u, err := strconv.ParseUint(v, 10, 64); err == nil {
+				dst.SetUint(u)
+				return nil
+			}
+		}
+	case reflect.Float32, reflect.Float64:
+		switch v := src.(type) {
+		case float64:
+			dst.SetFloat(v)
+			return nil
+		case int:
+			dst.SetFloat(float64(v))
+			return nil
+		case string:
+			if f, err := strconv.ParseFloat(v, 64); err == nil {
+				dst.SetFloat(f)
+				return nil
+			}
+		}
+	case reflect.Slice:
+		list, ok := src.([]any)
+		if !ok {
+			break
+		}
+		out := reflect.MakeSlice(dst.Type(), len(list), len(list))
+		for i, item := range list {
+			if err := decodeValue(fmt.Sprintf("%s[%d]", key, i), item, out.Index(i)); err != nil {
+				return err
+			}
+		}
+		dst.Set(out)
+		return nil
+	case reflect.Map:
+		m, ok := src.(map[string]any)
+		if !ok || dst.Type().Key().Kind() != reflect.String {
+			break
+		}
+		out := reflect.MakeMapWithSize(dst.Type(), len(m))
+		for k, item := range m {
+			elem := reflect.New(dst.Type().Elem()).Elem()
+			if err := decodeValue(joinKey(key, k), item, elem); err != nil {
+				return err
+			}
+			out.SetMapIndex(reflect.ValueOf(k).Convert(dst.Type().Key()), elem)
+		}
+		dst.Set(out)
+		return nil
+	case reflect.Struct:
+		m, ok := src.(map[string]any)
+		if !ok {
+			break
+		}
+		return decodeStruct(key, m, dst)
+	}
+
+	return fmt.Errorf("key '%s': cannot decode %T into %s", key, src, dst.Type())
+}
+
+func decodeStruct(key string, m map[string]any, dst reflect.Value) error {
+	t := dst.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name := field.Name
+		if tag, ok := field.Tag.Lookup("dml"); ok {
+			tag = strings.Split(tag, ",")[0]
+			if tag == "-" {
+				continue
+			}
+			if tag != "" {
+				name = tag
+			}
+		}
+
+		val, exists := m[name]
+		if !exists {
+			for k, v := range m {
+				if strings.EqualFold(k, name) {
+					val, exists = v, true
+					break
+				}
+			}
+		}
+		if !exists {
+			continue
+		}
+
+		if err := decodeValue(joinKey(key, name), val, dst.Field(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func toDuration(src any) (time.Duration, error) {
+	switch v := src.(type) {
+	case time.Duration:
+		return v, nil
+	case int:
+		return time.Duration(v), nil
+	case string:
+		return time.ParseDuration(v)
+	}
+	return 0, fmt.Errorf("cannot decode %T into time.Duration", src)
+}
+
+func joinKey(prefix, key string) string {
+	if prefix == "" {
+		return key
+	}
+	return prefix + "." + key
+}
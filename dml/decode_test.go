@@ -0,0 +1,80 @@
+package dml
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDecode_Struct(t *testing.T) {
+	type TLS struct {
+		Enabled bool `dml:"enabled"`
+	}
+	type Server struct {
+		Port        int           `dml:"port"`
+		ReadTimeout time.Duration `dml:"read_timeout"`
+		TLS         TLS           `dml:"tls"`
+	}
+	type Backend struct {
+		URL    string `dml:"url"`
+		Weight int    `dml:"weight"`
+	}
+	type Config struct {
+		Name     string        `dml:"name"`
+		Server   Server        `dml:"server"`
+		Ratio    float64       `dml:"ratio"`
+		Grace    time.Duration `dml:"grace"`
+		Ports    []int         `dml:"ports"`
+		Backends []Backend     `dml:"backends"`
+		Ignored  string        `dml:"-"`
+	}
+
+	cfg := New()
+	if err := cfg.Parse(codegenSource); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var out Config
+	if err := cfg.Decode(&out); err != nil {
+		t.Fatalf("Decode: %v", err)
+	}
+
+	if out.Name != "api" {
+		t.Errorf("expected name=api, got %q", out.Name)
+	}
+	if out.Server.Port != 8080 || out.Server.ReadTimeout != 15*time.Second || !out.Server.TLS.Enabled {
+		t.Errorf("unexpected server: %+v", out.Server)
+	}
+	if out.Ratio != 0.5 {
+		t.Errorf("expected ratio=0.5, got %v", out.Ratio)
+	}
+	if out.Grace != 30*time.Second {
+		t.Errorf("expected grace=30s, got %v", out.Grace)
+	}
+	if len(out.Ports) != 2 || out.Ports[1] != 443 {
+		t.Errorf("unexpected ports: %v", out.Ports)
+	}
+	if len(out.Backends) != 1 || out.Backends[0].URL != "http://a" || out.Backends[0].Weight != 1 {
+		t.Errorf("unexpected backends: %+v", out.Backends)
+	}
+}
+
+func TestDecode_TypeMismatch(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse(`string port = "abc";`); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	var out struct {
+		Port int `dml:"port"`
+	}
+	if err := cfg.Decode(&out); err == nil {
+		t.Fatal("expected error decoding string into int")
+	}
+}
+
+func TestDecode_RequiresPointer(t *testing.T) {
+	var out struct{}
+	if err := New().Decode(out); err == nil {
+		t.Fatal("expected error for non-pointer target")
+	}
+}
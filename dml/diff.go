@@ -0,0 +1,170 @@
+package dml
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+type ChangeKind int
+
+const (
+	ChangeAdded ChangeKind = iota
+	ChangeRemoved
+	ChangeModified
+	// ChangeType marks a key whose value changed to a different DML type,
+	// such as an int becoming a string or a map becoming a scalar.
+	ChangeType
+)
+
+func (k ChangeKind) String() string {
+	switch k {
+	case ChangeAdded:
+		return "added"
+	case ChangeRemoved:
+		return "removed"
+	case ChangeModified:
+		return "modified"
+	case ChangeType:
+		return "type"
+	default:
+		return "unknown"
+	}
+}
+
+// Change describes one difference between two configs. Old is unset for
+// added keys and New is unset for removed ones.
+type Change struct {
+	Key     string
+	Kind    ChangeKind
+	Old     any
+	New     any
+	OldType string
+	NewType string
+}
+
+func (c Change) MarshalJSON() ([]byte, error) {
+	out := map[string]any{"key": c.Key, "kind": c.Kind.String()}
+	if c.Kind != ChangeAdded {
+		out["old"] = durationsAsText(c.Old)
+		out["oldType"] = c.OldType
+	}
+	if c.Kind != ChangeRemoved {
+		out["new"] = durationsAsText(c.New)
+		out["newType"] = c.NewType
+	}
+	return json.Marshal(out)
+}
+
+// Diff compares two configs key by key. Maps are descended into so that
+// changes are reported at dotted-key granularity; lists are compared as
+// whole values. Changes are sorted by key.
+func Diff(a, b *Config) []Change {
+	var changes []Change
+	diffMaps(&changes, "", a.data, b.data)
+	sort.SliceStable(changes, func(i, j int) bool { return changes[i].Key < changes[j].Key })
+	return changes
+}
+
+func diffMaps(changes *[]Change, prefix string, a, b map[string]any) {
+	for k, oldVal := range a {
+		key := joinKey(prefix, k)
+		newVal, ok := b[k]
+		if !ok {
+			*changes = append(*changes, Change{Key: key, Kind: ChangeRemoved, Old: oldVal, OldType: dmlTypeOf(oldVal)})
+			continue
+		}
+		diffValues(changes, key, oldVal, newVal)
+	}
+	for k, newVal := range b {
+		if _, ok := a[k]; !ok {
+			*changes = append(*changes, Change{Key: joinKey(prefix, k), Kind: ChangeAdded, New: newVal, NewType: dmlTypeOf(newVal)})
+		}
+	}
+}
+
+func diffValues(changes *[]Change, key string, oldVal, newVal any) {
+	oldMap, oldIsMap := oldVal.(map[string]any)
+	newMap, newIsMap := newVal.(map[string]any)
+	if oldIsMap && newIsMap {
+		diffMaps(changes, key, oldMap, newMap)
+		return
+	}
+
+	if reflect.DeepEqual(oldVal, newVal) {
+		return
+	}
+
+	c := Change{Key: key, Kind: ChangeModified, Old: oldVal, New: newVal,
+		OldType: dmlTypeOf(oldVal), NewType: dmlTypeOf(newVal)}
+	if c.OldType != c.NewType {
+		c.Kind = ChangeType
+	}
+	*changes = append(*changes, c)
+}
+
+// JSONPatch renders changes as an RFC 6902 JSON Patch that turns the old
+// config into the new one.
+func JSONPatch(changes []Change) ([]byte, error) {
+	type op struct {
+		Op    string          `json:"op"`
+		Path  string          `json:"path"`
+		Value json.RawMessage `json:"value,omitempty"`
+	}
+
+	ops := make([]op, 0, len(changes))
+	for _, c := range changes {
+		o := op{Op: "replace", Path: keyToPointer(c.Key)}
+		switch c.Kind {
+		case ChangeAdded:
+			o.Op = "add"
+		case ChangeRemoved:
+			o.Op = "remove"
+		}
+		if c.Kind != ChangeRemoved {
+			value, err := json.Marshal(durationsAsText(c.New))
+			if err != nil {
+				return nil, err
+			}
+			o.Value = value
+		}
+		ops = append(ops, o)
+	}
+	return json.MarshalIndent(ops, "", "  ")
+}
+
+// durationsAsText replaces durations in v with their text form, such as
+// "30s", which LoadJSON and ApplyPatch read back for duration keys.
+// encoding/json would write them as integer nanoseconds.
+func durationsAsText(v any) any {
+	switch val := v.(type) {
+	case time.Duration:
+		return val.String()
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = durationsAsText(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = durationsAsText(item)
+		}
+		return out
+	}
+	return v
+}
+
+// keyToPointer converts a dotted key into a JSON Pointer (RFC 6901).
+func keyToPointer(key string) string {
+	var sb strings.Builder
+	for _, part := range strings.Split(key, ".") {
+		sb.WriteByte('/')
+		part = strings.ReplaceAll(part, "~", "~0")
+		sb.WriteString(strings.ReplaceAll(part, "/", "~1"))
+	}
+	return sb.String()
+}
@@ -0,0 +1,138 @@
+package dml
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustParse(t *testing.T, src string) *Config {
+	t.Helper()
+	cfg := New()
+	if err := cfg.Parse(src); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	return cfg
+}
+
+func TestDiff_DottedKeys(t *testing.T) {
+	a := mustParse(t, `int port = 8080;
+string host = "localhost";
+map db = {"url": "postgres://a", "pool": 5};
+list tags = ["a", "b"];
+bool debug = false;`)
+	b := mustParse(t, `int port = 9090;
+string host = "localhost";
+map db = {"url": "postgres://a", "pool": 5, "ssl": true};
+list tags = ["a"];
+string debug = "false";`)
+
+	changes := Diff(a, b)
+	want := []struct {
+		key  string
+		kind ChangeKind
+	}{
+		{"db.ssl", ChangeAdded},
+		{"debug", ChangeType},
+		{"port", ChangeModified},
+		{"tags", ChangeModified},
+	}
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %+v", len(want), changes)
+	}
+	for i, w := range want {
+		if changes[i].Key != w.key || changes[i].Kind != w.kind {
+			t.Errorf("change %d: expected %s %s, got %s %s", i, w.kind, w.key, changes[i].Kind, changes[i].Key)
+		}
+	}
+	if changes[1].OldType != "bool" || changes[1].NewType != "string" {
+		t.Errorf("expected bool -> string, got %s -> %s", changes[1].OldType, changes[1].NewType)
+	}
+
+	if len(Diff(a, a)) != 0 {
+		t.Error("expected no changes between a config and itself")
+	}
+}
+
+func TestDiff_MapReplacedByScalar(t *testing.T) {
+	a := mustParse(t, `map server = {"port": 80};`)
+	b := mustParse(t, `string server = "web";`)
+
+	changes := Diff(a, b)
+	if len(changes) != 1 || changes[0].Key != "server" || changes[0].Kind != ChangeType {
+		t.Fatalf("expected one type change on server, got %+v", changes)
+	}
+	if len(Diff(b, a)) != 1 {
+		t.Errorf("expected the reverse diff to be symmetric")
+	}
+}
+
+func TestJSONPatch(t *testing.T) {
+	a := mustParse(t, `map db = {"pool": 5, "a/b": 1};
+int port = 80;`)
+	b := mustParse(t, `map db = {"pool": 0};
+bool debug = false;`)
+
+	patch, err := JSONPatch(Diff(a, b))
+	if err != nil {
+		t.Fatalf("JSONPatch: %v", err)
+	}
+
+	var ops []map[string]any
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, patch)
+	}
+	want := []string{
+		`{"op":"remove","path":"/db/a~1b"}`,
+		`{"op":"replace","path":"/db/pool","value":0}`,
+		`{"op":"add","path":"/debug","value":false}`,
+		`{"op":"remove","path":"/port"}`,
+	}
+	if len(ops) != len(want) {
+		t.Fatalf("expected %d ops, got %s", len(want), patch)
+	}
+	for i, op := range ops {
+		got, _ := json.Marshal(op)
+		var norm map[string]any
+		json.Unmarshal([]byte(want[i]), &norm)
+		exp, _ := json.Marshal(norm)
+		if string(got) != string(exp) {
+			t.Errorf("op %d: expected %s, got %s", i, exp, got)
+		}
+	}
+}
+
+func TestJSONPatch_DurationRoundTrip(t *testing.T) {
+	a := mustParse(t, `duration timeout = "10s";
+list retries = [1, 2];`)
+	b := mustParse(t, `duration timeout = "1m30s";
+list retries = [1, 2];`)
+
+	changes := Diff(a, b)
+	patch, err := JSONPatch(changes)
+	if err != nil {
+		t.Fatalf("JSONPatch: %v", err)
+	}
+	if !strings.Contains(string(patch), `"value": "1m30s"`) {
+		t.Errorf("expected the duration as text in the patch:\n%s", patch)
+	}
+
+	out, err := json.Marshal(changes)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if !strings.Contains(string(out), `"old":"10s"`) || !strings.Contains(string(out), `"new":"1m30s"`) {
+		t.Errorf("expected durations as text in the change:\n%s", out)
+	}
+
+	if err := a.ApplyPatch(patch); err != nil {
+		t.Fatalf("ApplyPatch: %v\n%s", err, patch)
+	}
+	if v, _ := a.Get("timeout"); v != 90*time.Second {
+		t.Errorf("expected timeout 1m30s after applying the patch, got %#v", v)
+	}
+	if changes := Diff(a, b); len(changes) != 0 {
+		t.Errorf("expected no differences after applying the patch, got %+v", changes)
+	}
+}
@@ -0,0 +1,186 @@
+package dml
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ParseEnv reads a .env file and returns its variables without touching the
+// process environment. It follows the common dotenv conventions:
+//
+//	# comment
+//	export KEY=value          export is optional
+//	KEY=value # comment       unquoted values end at " #"
+//	KEY='literal ${NOT}'      single quotes (and backticks) are taken as is
+//	KEY="line\nnext"          double quotes understand \n \r \t \\ \" \$
+//	KEY="first               quoted values may span lines
+//	second"
+//	URL=http://${HOST}:$PORT  references use the syntax of Interpolate
+//
+// References in unquoted and double-quoted values are resolved against the
+// variables defined earlier in the file, then the process environment.
+func ParseEnv(r io.Reader) (map[string]string, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("error reading .env file: %w", err)
+	}
+	return parseDotenv(string(data), nil, os.LookupEnv)
+}
+
+// parseDotenv parses src, resolving references against the variables the
+// file defined earlier, then lookupEnv. kept, when not nil, is asked before
+// the file's own variables, for callers that keep environment values over
+// the file's.
+func parseDotenv(src string, kept, lookupEnv func(string) (string, bool)) (map[string]string, error) {
+	vars := make(map[string]string)
+	lookup := func(name string) (string, bool) {
+		if kept != nil {
+			if v, ok := kept(name); ok {
+				return v, true
+			}
+		}
+		if v, ok := vars[name]; ok {
+			return v, true
+		}
+		return lookupEnv(name)
+	}
+
+	lines := strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNum := i + 1
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if rest, ok := cutExport(line); ok {
+			line = rest
+		}
+
+		eq := strings.IndexByte(line, '=')
+		if eq < 0 {
+			return nil, fmt.Errorf("invalid .env format at line %d: %s", lineNum, line)
+		}
+		key := strings.TrimSpace(line[:eq])
+		if !isEnvName(key) {
+			return nil, fmt.Errorf("invalid variable name %q at line %d", key, lineNum)
+		}
+		raw := strings.TrimLeft(line[eq+1:], " \t")
+
+		var value string
+		if raw != "" && strings.IndexByte("\"'`", raw[0]) >= 0 {
+			quote, body := raw[0], raw[1:]
+			end := closingQuote(body, quote)
+			for end < 0 && i+1 < len(lines) {
+				i++
+				body += "\n" + lines[i]
+				end = closingQuote(body, quote)
+			}
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated quoted value for %s at line %d", key, lineNum)
+			}
+			if rest := strings.TrimSpace(body[end+1:]); rest != "" && !strings.HasPrefix(rest, "#") {
+				return nil, fmt.Errorf("unexpected text after quoted value for %s at line %d: %s", key, lineNum, rest)
+			}
+			value = body[:end]
+			if quote != '"' {
+				vars[key] = value
+				continue
+			}
+			value = unescapeDotenv(value)
+		} else {
+			value = stripInlineComment(line[eq+1:])
+		}
+
+		expanded, err := Interpolate(value, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value for %s at line %d: %w", key, lineNum, err)
+		}
+		vars[key] = expanded
+	}
+	return vars, nil
+}
+
+// cutExport removes a leading "export" keyword.
+func cutExport(line string) (string, bool) {
+	rest, ok := strings.CutPrefix(line, "export")
+	if !ok || rest == "" || (rest[0] != ' ' && rest[0] != '\t') {
+		return line, false
+	}
+	return strings.TrimLeft(rest, " \t"), true
+}
+
+// isEnvName accepts the names dotenv files use in practice: a letter or
+// underscore, then letters, digits, underscores, dots or dashes.
+func isEnvName(name string) bool {
+	if name == "" || !isNameStart(name[0]) {
+		return false
+	}
+	for i := 1; i < len(name); i++ {
+		if c := name[i]; !isNameChar(c) && c != '.' && c != '-' {
+			return false
+		}
+	}
+	return true
+}
+
+// closingQuote returns the index of the quote ending body, skipping
+// backslash escapes inside double quotes.
+func closingQuote(body string, quote byte) int {
+	for i := 0; i < len(body); i++ {
+		switch body[i] {
+		case '\\':
+			if quote == '"' {
+				i++
+			}
+		case quote:
+			return i
+		}
+	}
+	return -1
+}
+
+// unescapeDotenv decodes the escapes of a double-quoted value. An escaped
+// dollar becomes "$$" so that Interpolate keeps it literal.
+func unescapeDotenv(s string) string {
+	if !strings.Contains(s, `\`) {
+		return s
+	}
+	var sb strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			continue
+		}
+		i++
+		switch s[i] {
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case '\\', '"':
+			sb.WriteByte(s[i])
+		case '$':
+			sb.WriteString("$$")
+		default:
+			sb.WriteByte('\\')
+			sb.WriteByte(s[i])
+		}
+	}
+	return sb.String()
+}
+
+// stripInlineComment cuts an unquoted value at a '#' that follows
+// whitespace, and trims it.
+func stripInlineComment(s string) string {
+	for i := 1; i < len(s); i++ {
+		if s[i] == '#' && (s[i-1] == ' ' || s[i-1] == '\t') {
+			s = s[:i]
+			break
+		}
+	}
+	return strings.TrimSpace(s)
+}
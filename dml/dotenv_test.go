@@ -0,0 +1,75 @@
+package dml
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestParseEnv(t *testing.T) {
+	t.Setenv("DML_TEST_OUTER", "from-os")
+
+	src := "# comment\n" +
+		"export HOST=localhost\n" +
+		"PORT = 5432 # inline comment\n" +
+		"HASH=abc#def\n" +
+		"EMPTY=\n" +
+		"EMPTY_COMMENT= # nothing\n" +
+		"SINGLE='literal ${HOST} \\n'\n" +
+		"BACKTICK=`it's`\n" +
+		"DOUBLE=\"tab\\there \\\"quoted\\\" \\$HOST\"\n" +
+		"MULTI=\"first\n" +
+		"second\" # trailing\n" +
+		"URL=postgres://${HOST}:$PORT/db\n" +
+		"OUTER=${DML_TEST_OUTER}\n" +
+		"FALLBACK=\"${DML_TEST_UNSET:-default}\"\n" +
+		"CRLF=value\r\n" +
+		"DML_TEST_PARSE_ONLY=1\n"
+
+	vars, err := ParseEnv(strings.NewReader(src))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"HOST":                "localhost",
+		"PORT":                "5432",
+		"HASH":                "abc#def",
+		"EMPTY":               "",
+		"EMPTY_COMMENT":       "",
+		"SINGLE":              "literal ${HOST} \\n",
+		"BACKTICK":            "it's",
+		"DOUBLE":              "tab\there \"quoted\" $HOST",
+		"MULTI":               "first\nsecond",
+		"URL":                 "postgres://localhost:5432/db",
+		"OUTER":               "from-os",
+		"FALLBACK":            "default",
+		"CRLF":                "value",
+		"DML_TEST_PARSE_ONLY": "1",
+	}
+	for key, expected := range want {
+		if got, ok := vars[key]; !ok || got != expected {
+			t.Errorf("%s = %q (set %v), want %q", key, got, ok, expected)
+		}
+	}
+	if len(vars) != len(want) {
+		t.Errorf("expected %d variables, got %d: %v", len(want), len(vars), vars)
+	}
+	if _, set := os.LookupEnv("DML_TEST_PARSE_ONLY"); set {
+		t.Error("ParseEnv must not change the process environment")
+	}
+}
+
+func TestParseEnv_Errors(t *testing.T) {
+	tests := map[string]string{
+		"missing equals": "JUST_A_NAME\n",
+		"bad name":       "1KEY=value\n",
+		"unterminated":   "KEY=\"never closed\nOTHER=1\n",
+		"trailing text":  "KEY=\"quoted\" extra\n",
+		"required":       "KEY=${DML_TEST_UNSET:?must be set}\n",
+	}
+	for name, src := range tests {
+		if _, err := ParseEnv(strings.NewReader(src)); err == nil {
+			t.Errorf("%s: expected an error for %q", name, src)
+		}
+	}
+}
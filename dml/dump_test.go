@@ -0,0 +1,258 @@
+package dml
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+const dumpStringAlphabet = "abcXYZ019 _-.:/,;=@#{}[]()<>!?*&%$'\\\t\r"
+
+// dumpUnrepresentable holds the characters DML strings cannot contain; the
+// generator slips one into some strings to exercise ToDML's check.
+const dumpUnrepresentable = "\"\n"
+
+type dumpGen struct {
+	r *rand.Rand
+}
+
+func (g dumpGen) ident() string {
+	const first = "abcdefghijklmnopqrstuvwxyz_"
+	const rest = first + "0123456789"
+	var sb strings.Builder
+	sb.WriteByte(first[g.r.Intn(len(first))])
+	for i := g.r.Intn(6); i > 0; i-- {
+		sb.WriteByte(rest[g.r.Intn(len(rest))])
+	}
+	return sb.String()
+}
+
+func (g dumpGen) str() string {
+	var sb strings.Builder
+	for i := g.r.Intn(12); i > 0; i-- {
+		sb.WriteByte(dumpStringAlphabet[g.r.Intn(len(dumpStringAlphabet))])
+	}
+	if g.r.Intn(40) == 0 {
+		sb.WriteByte(dumpUnrepresentable[g.r.Intn(len(dumpUnrepresentable))])
+	}
+	return sb.String()
+}
+
+// representable reports whether every string, map key and float in value
+// can be written as DML.
+func representable(value any) bool {
+	switch v := value.(type) {
+	case float64:
+		return !math.IsNaN(v) && !math.IsInf(v, 0)
+	case string:
+		return !strings.ContainsAny(v, dumpUnrepresentable)
+	case map[string]any:
+		for k, item := range v {
+			if strings.ContainsAny(k, dumpUnrepresentable) || !representable(item) {
+				return false
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if !representable(item) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// dumpFloatEdges are floats the generator returns now and then. The
+// non-finite ones have no DML form and exercise ToDML's check.
+var dumpFloatEdges = []float64{
+	0, math.Copysign(0, -1), math.MaxFloat64, -math.MaxFloat64, math.SmallestNonzeroFloat64,
+	math.NaN(), math.Inf(1), math.Inf(-1),
+}
+
+func (g dumpGen) float() float64 {
+	if g.r.Intn(40) == 0 {
+		return dumpFloatEdges[g.r.Intn(len(dumpFloatEdges))]
+	}
+	switch g.r.Intn(4) {
+	case 0:
+		return float64(g.r.Intn(200) - 100)
+	case 1:
+		return g.r.NormFloat64() * math.Pow(10, float64(g.r.Intn(40)-20))
+	default:
+		return math.Round(g.r.Float64()*10000) / 100
+	}
+}
+
+func (g dumpGen) scalar() any {
+	switch g.r.Intn(4) {
+	case 0:
+		return g.str()
+	case 1:
+		return g.r.Intn(1<<20) - 1<<19
+	case 2:
+		return g.float()
+	default:
+		return g.r.Intn(2) == 0
+	}
+}
+
+func (g dumpGen) value(depth int) any {
+	if depth <= 0 {
+		return g.scalar()
+	}
+	switch g.r.Intn(6) {
+	case 0:
+		return g.list(depth - 1)
+	case 1:
+		return g.mapValue(depth - 1)
+	default:
+		return g.scalar()
+	}
+}
+
+func (g dumpGen) list(depth int) []any {
+	list := make([]any, g.r.Intn(4))
+	for i := range list {
+		list[i] = g.value(depth)
+	}
+	return list
+}
+
+func (g dumpGen) mapValue(depth int) map[string]any {
+	m := make(map[string]any)
+	for i := g.r.Intn(6); i > 0; i-- {
+		m[g.ident()] = g.value(depth)
+	}
+	return m
+}
+
+// config builds a config the way Parse would, recording a declared type for
+// every top-level key, including the number/boolean aliases.
+func (g dumpGen) config() *Config {
+	cfg := New()
+	for i := g.r.Intn(8) + 1; i > 0; i-- {
+		key := g.ident()
+		var value any
+		if g.r.Intn(8) == 0 {
+			value = time.Duration(g.r.Intn(100000)) * time.Millisecond
+		} else {
+			value = g.value(3)
+		}
+		cfg.Set(key, value)
+
+		typeName := dmlTypeOf(value)
+		if typeName == "int" && g.r.Intn(2) == 0 {
+			typeName = "number"
+		}
+		if typeName == "bool" && g.r.Intn(2) == 0 {
+			typeName = "boolean"
+		}
+		cfg.setType(key, typeName)
+	}
+	cfg.SetMapStyle([]MapStyle{MapStyleAuto, MapStyleJSON, MapStyleFlat}[g.r.Intn(3)])
+	return cfg
+}
+
+func TestDump_RoundTripProperty(t *testing.T) {
+	g := dumpGen{r: rand.New(rand.NewSource(29))}
+
+	rejected := 0
+	for i := 0; i < 2000; i++ {
+		cfg := g.config()
+		dumped, err := cfg.ToDML()
+		if !representable(cfg.data) {
+			if err == nil {
+				t.Fatalf("case %d: ToDML accepted a value DML cannot hold\n%s", i, dumped)
+			}
+			rejected++
+			continue
+		}
+		if err != nil {
+			t.Fatalf("case %d: ToDML: %v", i, err)
+		}
+
+		back := New()
+		if err := back.Parse(dumped); err != nil {
+			t.Fatalf("case %d: Parse(Dump(cfg)) failed: %v\n%s", i, err, dumped)
+		}
+
+		if !reflect.DeepEqual(cfg.data, back.data) {
+			t.Fatalf("case %d: round trip mismatch\nwant: %#v\ngot:  %#v\n%s", i, cfg.data, back.data, dumped)
+		}
+		if back.mapStyle != cfg.mapStyle {
+			t.Fatalf("case %d: map style not preserved", i)
+		}
+
+		for key, want := range cfg.types {
+			if _, isMap := cfg.data[key].(map[string]any); isMap {
+				continue
+			}
+			if got, _ := back.DeclaredType(key); got != want {
+				t.Fatalf("case %d: declared type of %q: want %q, got %q\n%s", i, key, want, got, dumped)
+			}
+		}
+	}
+	if rejected == 0 {
+		t.Fatal("generator never produced an unrepresentable value")
+	}
+}
+
+func TestToDML_RejectsUnrepresentableValues(t *testing.T) {
+	for _, value := range []any{
+		[]any{"a\",b", "c"},
+		"line1\nline2",
+		`"quoted"`,
+		map[string]any{"note": "say \"hi\""},
+		map[string]any{"a\nb": 1},
+		math.NaN(),
+		[]any{1.5, math.Inf(1)},
+		map[string]any{"low": math.Inf(-1)},
+	} {
+		cfg := New()
+		cfg.Set("v", value)
+		if out, err := cfg.ToDML(); err == nil {
+			t.Errorf("expected ToDML to reject %#v, got:\n%s", value, out)
+		} else if !strings.Contains(err.Error(), "'v") {
+			t.Errorf("expected error to name the key, got %v", err)
+		}
+	}
+
+	cfg := New()
+	cfg.Set("path", `C:\dir\file`)
+	out, err := cfg.ToDML()
+	if err != nil {
+		t.Fatalf("ToDML: %v", err)
+	}
+	back := New()
+	if err := back.Parse(out); err != nil {
+		t.Fatalf("Parse: %v\n%s", err, out)
+	}
+	if got := back.GetString("path"); got != `C:\dir\file` {
+		t.Errorf("expected backslashes to round-trip, got %q", got)
+	}
+}
+
+func TestDump_PreservesFloatType(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse("float ratio = 0.5;\nfloat whole = 2.0;\nnumber port = 8080;\nboolean debug = true;"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	dumped := cfg.Dump()
+	for _, want := range []string{"float ratio = 0.5;", "float whole = 2.0;", "number port = 8080;", "boolean debug = true;"} {
+		if !strings.Contains(dumped, want) {
+			t.Errorf("expected %q in dump:\n%s", want, dumped)
+		}
+	}
+
+	back := New()
+	if err := back.Parse(dumped); err != nil {
+		t.Fatalf("re-parse failed: %v\n%s", err, dumped)
+	}
+	if v, _ := back.Get("whole"); v != 2.0 {
+		t.Errorf("expected whole to stay float64, got %#v", v)
+	}
+}
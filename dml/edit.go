@@ -0,0 +1,379 @@
+package dml
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Editor applies minimal textual changes to a DML file. Comments, declaration
+// order and formatting outside the edited values are left byte-identical.
+type Editor struct {
+	path string
+	src  string
+	err  error
+}
+
+func Edit(path string) *Editor {
+	content, err := os.ReadFile(path)
+	return &Editor{path: path, src: string(content), err: err}
+}
+
+func EditSource(src []byte) *Editor {
+	return &Editor{src: string(src)}
+}
+
+func (e *Editor) Set(key string, value any) *Editor {
+	if e.err != nil {
+		return e
+	}
+
+	if isPathExpr(key) {
+		e.src, e.err = e.setPath(key, value)
+		return e
+	}
+
+	f := scanCST(e.src)
+	if n := f.lastDecl(key); n != nil {
+		e.src, e.err = f.replaceDeclValue(n, value, key)
+		return e
+	}
+
+	if n, rest := f.enclosingMapDecl(key); n != nil {
+		e.src, e.err = f.setInLiteral(n.value, rest, value, key)
+		return e
+	}
+
+	e.src = f.insertDecl(key, value)
+	return e
+}
+
+// setPath edits the value addressed by an indexed path such as
+// "servers[1].host" inside an existing literal.
+func (e *Editor) setPath(key string, value any) (string, error) {
+	segs, err := parsePath(key)
+	if err != nil {
+		return "", err
+	}
+	if !singular(segs) {
+		return "", fmt.Errorf("cannot set '%s': path matches more than one value", key)
+	}
+
+	var names []string
+	for _, seg := range segs {
+		if seg.kind != segmentField {
+			break
+		}
+		names = append(names, seg.name)
+	}
+
+	f := scanCST(e.src)
+	var n *cstNode
+	i := len(names)
+	for ; i > 0; i-- {
+		if n = f.lastDecl(strings.Join(names[:i], ".")); n != nil {
+			break
+		}
+	}
+	if n == nil {
+		return "", fmt.Errorf("cannot set '%s': no declaration contains it", key)
+	}
+	if i == len(segs) {
+		return f.replaceDeclValue(n, value, key)
+	}
+
+	s := n.value
+	for j := i; j < len(segs); j++ {
+		seg := segs[j]
+		entries := f.entries(s)
+		found := -1
+		switch seg.kind {
+		case segmentField:
+			for k := len(entries) - 1; k >= 0; k-- {
+				if entries[k].key.valid() && entries[k].name == seg.name {
+					found = k
+					break
+				}
+			}
+			if found < 0 && f.src[s.start] == '{' {
+				var rest []string
+				for _, r := range segs[j:] {
+					if r.kind != segmentField {
+						return "", fmt.Errorf("cannot set '%s': '%s' does not exist", key, seg.name)
+					}
+					rest = append(rest, r.name)
+				}
+				return f.setInLiteral(s, rest, value, key)
+			}
+		case segmentIndex:
+			idx := seg.index
+			if idx < 0 {
+				idx += len(entries)
+			}
+			if f.src[s.start] == '[' && idx >= 0 && idx < len(entries) {
+				found = idx
+			}
+		}
+		if found < 0 {
+			return "", fmt.Errorf("cannot set '%s': path does not exist", key)
+		}
+		s = entries[found].value
+	}
+	return applyEdits(f.src, []textEdit{{start: s.start, end: s.end, text: formatLiteral(value)}}), nil
+}
+
+func (e *Editor) Delete(key string) *Editor {
+	if e.err != nil {
+		return e
+	}
+
+	f := scanCST(e.src)
+	var edits []textEdit
+	for i, n := range f.nodes {
+		if n.kind != cstDecl || !n.name.valid() {
+			continue
+		}
+		name := n.declName(f)
+		if name != key && !strings.HasPrefix(name, key+".") {
+			continue
+		}
+		start := n.start
+		for j := i - 1; j >= 0 && f.nodes[j].kind == cstComment; j-- {
+			start = f.nodes[j].start
+		}
+		edits = append(edits, textEdit{start: start, end: n.end})
+	}
+	if len(edits) > 0 {
+		e.src = applyEdits(e.src, edits)
+		return e
+	}
+
+	if n, rest := f.enclosingMapDecl(key); n != nil {
+		e.src = f.deleteInLiteral(n.value, rest)
+	}
+	return e
+}
+
+// Bytes returns the edited source after checking that it still parses.
+func (e *Editor) Bytes() ([]byte, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	if err := New().Parse(e.src); err != nil {
+		return nil, fmt.Errorf("edited config is invalid: %w", err)
+	}
+	return []byte(e.src), nil
+}
+
+func (e *Editor) Save() error {
+	if e.path == "" {
+		return fmt.Errorf("editor has no file path")
+	}
+	out, err := e.Bytes()
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(e.path, out, 0644)
+}
+
+type textEdit struct {
+	start int
+	end   int
+	text  string
+}
+
+// applyEdits applies non-overlapping edits, which may be given in any order.
+func applyEdits(src string, edits []textEdit) string {
+	sorted := make([]textEdit, len(edits))
+	copy(sorted, edits)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].start > sorted[j].start })
+	for _, ed := range sorted {
+		src = src[:ed.start] + ed.text + src[ed.end:]
+	}
+	return src
+}
+
+func (f *cstFile) lastDecl(key string) *cstNode {
+	for i := len(f.nodes) - 1; i >= 0; i-- {
+		n := f.nodes[i]
+		if n.kind == cstDecl && n.name.valid() && n.declName(f) == key {
+			return n
+		}
+	}
+	return nil
+}
+
+// enclosingMapDecl finds the declaration whose map literal contains key,
+// returning it together with the remaining path inside the literal.
+func (f *cstFile) enclosingMapDecl(key string) (*cstNode, []string) {
+	parts := strings.Split(key, ".")
+	for i := len(parts) - 1; i > 0; i-- {
+		n := f.lastDecl(strings.Join(parts[:i], "."))
+		if n != nil && n.value.valid() && f.src[n.value.start] == '{' {
+			return n, parts[i:]
+		}
+	}
+	return nil, nil
+}
+
+func formatLiteral(value any) string {
+	var sb strings.Builder
+	(&Config{}).dumpInlineValue(&sb, value)
+	return sb.String()
+}
+
+// replaceDeclValue rewrites the value of a declaration, keeping its type
+// keyword. Values are converted to the declared type where that is lossless;
+// anything else is an error rather than a silent change of type.
+func (f *cstFile) replaceDeclValue(n *cstNode, value any, key string) (string, error) {
+	if n.typ.valid() {
+		declared := n.declType(f)
+		switch canonicalType(declared) {
+		case "float":
+			if i, ok := value.(int); ok {
+				value = float64(i)
+			}
+		case "duration":
+			if s, ok := value.(string); ok {
+				if d, err := time.ParseDuration(s); err == nil {
+					value = d
+				}
+			}
+		}
+		if !holdsType(declared, value) {
+			return "", fmt.Errorf("cannot set '%s': declared %s, got %s", key, declared, dmlTypeOf(value))
+		}
+	}
+	return applyEdits(f.src, []textEdit{{start: n.value.start, end: n.value.end, text: formatLiteral(value)}}), nil
+}
+
+func (f *cstFile) setInLiteral(s span, path []string, value any, key string) (string, error) {
+	entries := f.entries(s)
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.name != path[0] || !e.key.valid() {
+			continue
+		}
+		if len(path) == 1 {
+			return applyEdits(f.src, []textEdit{{start: e.value.start, end: e.value.end, text: formatLiteral(value)}}), nil
+		}
+		if !e.value.valid() || f.src[e.value.start] != '{' {
+			return "", fmt.Errorf("cannot set '%s': '%s' is not a map", key, path[0])
+		}
+		return f.setInLiteral(e.value, path[1:], value, key)
+	}
+
+	for i := len(path) - 1; i > 0; i-- {
+		value = map[string]any{path[i]: value}
+	}
+	entry := fmt.Sprintf("%q: %s", path[0], formatLiteral(value))
+	return applyEdits(f.src, []textEdit{f.insertEntry(s, entries, entry)}), nil
+}
+
+func (f *cstFile) insertEntry(s span, entries []cstEntry, entry string) textEdit {
+	if len(entries) == 0 {
+		return textEdit{start: s.start + 1, end: s.end - 1, text: entry}
+	}
+
+	last := entries[len(entries)-1]
+	prevEnd := s.start + 1
+	if len(entries) > 1 {
+		prevEnd = entries[len(entries)-2].end
+	}
+
+	if strings.Contains(f.src[prevEnd:last.start], "\n") {
+		lineStart := strings.LastIndexByte(f.src[:last.start], '\n') + 1
+		indent := f.src[lineStart:last.start]
+		if last.comma >= 0 {
+			return textEdit{start: last.comma + 1, end: last.comma + 1, text: "\n" + indent + entry + ","}
+		}
+		return textEdit{start: last.end, end: last.end, text: ",\n" + indent + entry}
+	}
+
+	if last.comma >= 0 {
+		return textEdit{start: last.comma + 1, end: last.comma + 1, text: " " + entry + ","}
+	}
+	return textEdit{start: last.end, end: last.end, text: ", " + entry}
+}
+
+func (f *cstFile) deleteInLiteral(s span, path []string) string {
+	entries := f.entries(s)
+	for i := len(entries) - 1; i >= 0; i-- {
+		e := entries[i]
+		if e.name != path[0] || !e.key.valid() {
+			continue
+		}
+		if len(path) > 1 {
+			if e.value.valid() && f.src[e.value.start] == '{' {
+				return f.deleteInLiteral(e.value, path[1:])
+			}
+			return f.src
+		}
+		return applyEdits(f.src, f.entryRemoval(entries, i))
+	}
+	return f.src
+}
+
+func (f *cstFile) entryRemoval(entries []cstEntry, i int) []textEdit {
+	e := entries[i]
+	isLast := i == len(entries)-1
+
+	tail := e.end
+	if e.comma >= 0 {
+		tail = e.comma + 1
+	}
+
+	lineStart := strings.LastIndexByte(f.src[:e.start], '\n') + 1
+	lineEnd := strings.IndexByte(f.src[tail:], '\n')
+	ownLine := lineEnd >= 0 &&
+		strings.TrimSpace(f.src[lineStart:e.start]) == "" &&
+		strings.TrimSpace(f.src[tail:tail+lineEnd]) == ""
+
+	if ownLine {
+		edits := []textEdit{{start: lineStart, end: tail + lineEnd + 1}}
+		if isLast && e.comma < 0 && i > 0 && entries[i-1].comma >= 0 {
+			c := entries[i-1].comma
+			edits = append(edits, textEdit{start: c, end: c + 1})
+		}
+		return edits
+	}
+
+	switch {
+	case !isLast:
+		return []textEdit{{start: e.start, end: entries[i+1].start}}
+	case i > 0:
+		return []textEdit{{start: entries[i-1].end, end: tail}}
+	default:
+		return []textEdit{{start: e.start, end: tail}}
+	}
+}
+
+// insertDecl adds a new declaration after the last one sharing its parent key,
+// or at the end of the file.
+func (f *cstFile) insertDecl(key string, value any) string {
+	line := fmt.Sprintf("%s %s = %s;\n", dmlTypeOf(value), key, formatLiteral(value))
+
+	pos := -1
+	if dot := strings.LastIndexByte(key, '.'); dot > 0 {
+		parent := key[:dot]
+		for _, n := range f.nodes {
+			if n.kind != cstDecl || !n.name.valid() {
+				continue
+			}
+			name := n.declName(f)
+			if name == parent || strings.HasPrefix(name, parent+".") {
+				pos = n.end
+			}
+		}
+	}
+	if pos < 0 {
+		pos = len(f.src)
+	}
+
+	if pos > 0 && f.src[pos-1] != '\n' {
+		line = "\n" + line
+	}
+	return f.src[:pos] + line + f.src[pos:]
+}
@@ -0,0 +1,267 @@
+package dml
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+const editSource = `@mapStyle json
+
+// Service name
+string name = "api";
+
+// HTTP server
+map server = {
+  "host": "localhost",
+  "port": 8080,
+  "tls": {"enabled": false}
+};
+
+float ratio = 0.5;
+
+// Legacy flag, to be removed
+bool legacy = true;
+
+string db.host = "db";
+`
+
+func editResult(t *testing.T, e *Editor) string {
+	t.Helper()
+	out, err := e.Bytes()
+	if err != nil {
+		t.Fatalf("Bytes: %v", err)
+	}
+	return string(out)
+}
+
+func TestEdit_SetTopLevelKeepsEverythingElse(t *testing.T) {
+	got := editResult(t, EditSource([]byte(editSource)).Set("name", "web"))
+	want := `@mapStyle json
+
+// Service name
+string name = "web";
+
+// HTTP server
+map server = {
+  "host": "localhost",
+  "port": 8080,
+  "tls": {"enabled": false}
+};
+
+float ratio = 0.5;
+
+// Legacy flag, to be removed
+bool legacy = true;
+
+string db.host = "db";
+`
+	if got != want {
+		t.Errorf("unexpected result:\n%s", got)
+	}
+}
+
+func TestEdit_SetInsideMapLiteral(t *testing.T) {
+	got := editResult(t, EditSource([]byte(editSource)).
+		Set("server.port", 9090).
+		Set("server.tls.enabled", true))
+
+	want := `@mapStyle json
+
+// Service name
+string name = "api";
+
+// HTTP server
+map server = {
+  "host": "localhost",
+  "port": 9090,
+  "tls": {"enabled": true}
+};
+
+float ratio = 0.5;
+
+// Legacy flag, to be removed
+bool legacy = true;
+
+string db.host = "db";
+`
+	if got != want {
+		t.Errorf("unexpected result:\n%s", got)
+	}
+}
+
+func TestEdit_AddEntryToMultiLineMap(t *testing.T) {
+	got := editResult(t, EditSource([]byte(editSource)).Set("server.timeout", 30))
+
+	cfg := New()
+	if err := cfg.Parse(got); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cfg.GetInt("server.timeout") != 30 {
+		t.Errorf("expected server.timeout=30:\n%s", got)
+	}
+	wantBlock := `  "tls": {"enabled": false},
+  "timeout": 30
+};`
+	if !strings.Contains(got, wantBlock) {
+		t.Errorf("expected new entry on its own line with matching indent:\n%s", got)
+	}
+}
+
+func TestEdit_SetKeepsDeclaredType(t *testing.T) {
+	src := editSource + "duration timeout = 10s;\n"
+	got := editResult(t, EditSource([]byte(src)).Set("ratio", 1).Set("timeout", "30s"))
+
+	if !strings.Contains(got, "float ratio = 1.0;") {
+		t.Errorf("expected float declaration to keep its type:\n%s", got)
+	}
+	if !strings.Contains(got, `duration timeout = "30s";`) {
+		t.Errorf("expected duration declaration to keep its type:\n%s", got)
+	}
+
+	cfg := New()
+	if err := cfg.Parse(got); err != nil {
+		t.Fatalf("Parse: %v\n%s", err, got)
+	}
+	if v, _ := cfg.Get("timeout"); v != 30*time.Second {
+		t.Errorf("expected timeout 30s, got %#v", v)
+	}
+}
+
+func TestEdit_SetRejectsValueOfOtherType(t *testing.T) {
+	for _, tc := range []struct {
+		key   string
+		value any
+	}{
+		{"legacy", "maybe"},
+		{"ratio", "fast"},
+		{"timeout", "soon"},
+		{"name", 3},
+	} {
+		src := editSource + "duration timeout = 10s;\n"
+		_, err := EditSource([]byte(src)).Set(tc.key, tc.value).Bytes()
+		if err == nil {
+			t.Errorf("Set(%q, %#v): expected a type error", tc.key, tc.value)
+		} else if !strings.Contains(err.Error(), tc.key) {
+			t.Errorf("Set(%q, %#v): expected error to name the key, got %v", tc.key, tc.value, err)
+		}
+	}
+}
+
+func TestEdit_SetNewKeys(t *testing.T) {
+	got := editResult(t, EditSource([]byte(editSource)).Set("db.port", 5432).Set("debug", false))
+
+	if !strings.Contains(got, "string db.host = \"db\";\nint db.port = 5432;\n") {
+		t.Errorf("expected db.port next to db.host:\n%s", got)
+	}
+	if !strings.Contains(got, "bool debug = false;\n") {
+		t.Errorf("expected debug appended:\n%s", got)
+	}
+}
+
+func TestEdit_Delete(t *testing.T) {
+	got := editResult(t, EditSource([]byte(editSource)).Delete("legacy").Delete("server.tls").Delete("missing"))
+
+	want := `@mapStyle json
+
+// Service name
+string name = "api";
+
+// HTTP server
+map server = {
+  "host": "localhost",
+  "port": 8080
+};
+
+float ratio = 0.5;
+
+
+string db.host = "db";
+`
+	if got != want {
+		t.Errorf("unexpected result:\n%s", got)
+	}
+}
+
+func TestEdit_DeleteInlineEntries(t *testing.T) {
+	src := "map m = {\"a\": 1, \"b\": 2, \"c\": 3};\n"
+
+	tests := map[string]string{
+		"m.a": "map m = {\"b\": 2, \"c\": 3};\n",
+		"m.b": "map m = {\"a\": 1, \"c\": 3};\n",
+		"m.c": "map m = {\"a\": 1, \"b\": 2};\n",
+	}
+	for key, want := range tests {
+		if got := editResult(t, EditSource([]byte(src)).Delete(key)); got != want {
+			t.Errorf("Delete(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+func TestEdit_SaveValidatesResult(t *testing.T) {
+	path := writeTempDML(t, editSource)
+
+	if err := Edit(path).Set("server.host.name", "x").Save(); err == nil {
+		t.Fatal("expected error setting a key below a scalar")
+	}
+
+	if err := Edit(path).Set("server.port", 9090).Save(); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	cfg, err := NewConfig(path)
+	if err != nil {
+		t.Fatalf("NewConfig: %v", err)
+	}
+	if cfg.GetInt("server.port") != 9090 {
+		t.Errorf("expected server.port=9090 after save")
+	}
+
+	if err := Edit(path+".missing").Set("a", 1).Save(); !os.IsNotExist(err) {
+		t.Errorf("expected not-exist error, got %v", err)
+	}
+}
+
+func TestApplyDefaults_KeepsCommentsAndOrder(t *testing.T) {
+	content := `// Public port
+number port = 8080;
+
+// Bind address
+string host = "localhost";
+`
+	path := writeTempDML(t, content)
+
+	err := ApplyDefaults(path, map[string]any{"port": 9000, "timeout": 30}, DefaultPolicyStrict)
+	if err != nil {
+		t.Fatalf("ApplyDefaults: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	want := content + "int timeout = 30;\n"
+	if string(got) != want {
+		t.Errorf("unexpected file after ApplyDefaults:\n%s", got)
+	}
+}
+
+func TestEdit_SetIndexedPath(t *testing.T) {
+	src := `// Backends.
+list servers = [{"host": "a", "port": 80}, {"host": "b", "port": 81}];
+`
+	got := editResult(t, EditSource([]byte(src)).Set("servers[1].host", "b2").Set("servers[-1].port", 8081))
+	want := `// Backends.
+list servers = [{"host": "a", "port": 80}, {"host": "b2", "port": 8081}];
+`
+	if got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if _, err := EditSource([]byte(src)).Set("servers[2].host", "c").Bytes(); err == nil {
+		t.Error("expected error for an index past the end of the list")
+	}
+	if _, err := EditSource([]byte(src)).Set("servers[*].host", "c").Bytes(); err == nil {
+		t.Error("expected error for a wildcard path")
+	}
+}
@@ -1,12 +1,15 @@
 package dml
 
 import (
-    "bufio"
     "fmt"
     "os"
+    "strconv"
     "strings"
+    "time"
 )
 
+// LoadEnv reads a .env file with ParseEnv and sets its variables in the
+// process environment, replacing values already set.
 func LoadEnv(filepath string) error {
     file, err := os.Open(filepath)
     if err != nil {
@@ -14,36 +17,15 @@ func LoadEnv(filepath string) error {
     }
     defer file.Close()
 
-    scanner := bufio.NewScanner(file)
-    lineNum := 0
-
-    for scanner.Scan() {
-        lineNum++
-        line := strings.TrimSpace(scanner.Text())
-
-        if line == "" || strings.HasPrefix(line, "#") {
-            continue
-        }
-
-        parts := strings.SplitN(line, "=", 2)
-        if len(parts) != 2 {
-            return fmt.Errorf("invalid .env format at line %d: %s", lineNum, line)
-        }
-
-        key := strings.TrimSpace(parts[0])
-        value := strings.TrimSpace(parts[1])
-
-        value = strings.Trim(value, `"'`)
-
+    vars, err := ParseEnv(file)
+    if err != nil {
+        return err
+    }
+    for key, value := range vars {
         if err := os.Setenv(key, value); err != nil {
             return fmt.Errorf("failed to set env var %s: %w", key, err)
         }
     }
-
-    if err := scanner.Err(); err != nil {
-        return fmt.Errorf("error reading .env file: %w", err)
-    }
-
     return nil
 }
 
@@ -54,8 +36,12 @@ func LoadEnvIfExists(filepath string) error {
     return LoadEnv(filepath)
 }
 
+// ExpandEnv expands references to the process environment in s with the
+// syntax described at Interpolate. Unsatisfied ${VAR:?} references expand
+// to an empty string; use Interpolate to get the error.
 func ExpandEnv(s string) string {
-    return os.ExpandEnv(s)
+    out, _ := Interpolate(s, os.LookupEnv)
+    return out
 }
 
 func GetEnv(key, defaultValue string) string {
@@ -73,32 +59,93 @@ func MustGetEnv(key string) string {
     return value
 }
 
-func (c *Config) LoadWithEnv() {
-    c.expandValues(c.data)
+// LoadWithEnv expands references to the environment given to SetEnv, or the
+// process environment, in string values at any depth of maps and lists. A
+// value declared with another type, such as `int port = ${PORT};`, is
+// parsed as that type once expanded. A missing
+// ${VAR:?} variable or a value of the wrong type is reported as a DMLError
+// at the declaration of the key; the config is then left unchanged.
+func (c *Config) LoadWithEnv() error {
+    data := deepCopy(c.data).(map[string]any)
+    if _, err := c.expandValue(data, ""); err != nil {
+        return err
+    }
+    c.data = data
+    return nil
 }
 
-func (c *Config) expandValues(data map[string]interface{}) {
-    for key, value := range data {
-        switch v := value.(type) {
-        case string:
-            data[key] = ExpandEnv(v)
-        case map[string]interface{}:
-            c.expandValues(v)
-        case []interface{}:
-            for i, item := range v {
-                if str, ok := item.(string); ok {
-                    v[i] = ExpandEnv(str)
-                }
+func (c *Config) expandValue(value any, path string) (any, error) {
+    switch v := value.(type) {
+    case string:
+        expanded, err := Interpolate(v, c.environment().LookupEnv)
+        if err != nil {
+            return nil, c.envError(path, err)
+        }
+        return c.typedEnvValue(path, expanded)
+    case map[string]any:
+        for key, item := range v {
+            expanded, err := c.expandValue(item, joinKey(path, key))
+            if err != nil {
+                return nil, err
+            }
+            v[key] = expanded
+        }
+    case []any:
+        for i, item := range v {
+            expanded, err := c.expandValue(item, fmt.Sprintf("%s[%d]", path, i))
+            if err != nil {
+                return nil, err
             }
+            v[i] = expanded
         }
     }
+    return value, nil
 }
 
+// typedEnvValue parses the expanded text of key according to its declared
+// type.
+func (c *Config) typedEnvValue(key, text string) (any, error) {
+    value, err := parseEnvScalar(c.types[key], text)
+    if err != nil {
+        line, col := 0, 0
+        if d, ok := c.declarationFor(key); ok {
+            line, col = d.Line, d.Column
+        }
+        return nil, c.newError(ErrorTypeType, "ENV_INVALID_VALUE", line, col, "", text, key, c.types[key])
+    }
+    return value, nil
+}
+
+// parseEnvScalar parses the value of an environment variable as the DML type
+// typeName. Booleans also accept the spellings common in environments, such
+// as 1, yes and on. Other types keep the text as is.
+func parseEnvScalar(typeName, text string) (any, error) {
+    trimmed := strings.TrimSpace(text)
+    switch canonicalType(typeName) {
+    case "int":
+        return strconv.Atoi(trimmed)
+    case "float":
+        return strconv.ParseFloat(trimmed, 64)
+    case "bool":
+        return parseBool(trimmed, 0, 0, "")
+    case "duration":
+        return time.ParseDuration(trimmed)
+    }
+    return text, nil
+}
+
+// SetEnvDefaults sets an environment variable for every value of the config
+// whose variable is empty, in the environment given to SetEnv or the process
+// environment.
 func (c *Config) SetEnvDefaults(prefix string) error {
-    return c.setEnvFromMap(c.data, prefix)
+    env, ok := c.environment().(MutableEnv)
+    if !ok {
+        return fmt.Errorf("cannot set environment defaults: %T is read-only", c.environment())
+    }
+    return c.setEnvFromMap(env, c.data, prefix)
 }
 
-func (c *Config) setEnvFromMap(data map[string]interface{}, prefix string) error {
+func (c *Config) setEnvFromMap(env MutableEnv, data map[string]interface{}, prefix string) error {
     for key, value := range data {
         envKey := key
         if prefix != "" {
@@ -108,31 +155,31 @@ func (c *Config) setEnvFromMap(data map[string]interface{}, prefix string) error
 
         switch v := value.(type) {
         case string:
-            if os.Getenv(envKey) == "" {
-                if err := os.Setenv(envKey, v); err != nil {
+            if getenv(env, envKey) == "" {
+                if err := env.Setenv(envKey, v); err != nil {
                     return err
                 }
             }
         case int:
-            if os.Getenv(envKey) == "" {
-                if err := os.Setenv(envKey, fmt.Sprintf("%d", v)); err != nil {
+            if getenv(env, envKey) == "" {
+                if err := env.Setenv(envKey, fmt.Sprintf("%d", v)); err != nil {
                     return err
                 }
             }
         case float64:
-            if os.Getenv(envKey) == "" {
-                if err := os.Setenv(envKey, fmt.Sprintf("%f", v)); err != nil {
+            if getenv(env, envKey) == "" {
+                if err := env.Setenv(envKey, formatDMLFloat(v)); err != nil {
                     return err
                 }
             }
         case bool:
-            if os.Getenv(envKey) == "" {
-                if err := os.Setenv(envKey, fmt.Sprintf("%t", v)); err != nil {
+            if getenv(env, envKey) == "" {
+                if err := env.Setenv(envKey, fmt.Sprintf("%t", v)); err != nil {
                     return err
                 }
             }
         case map[string]interface{}:
-            if err := c.setEnvFromMap(v, envKey); err != nil {
+            if err := c.setEnvFromMap(env, v, envKey); err != nil {
                 return err
             }
         }
@@ -140,53 +187,16 @@ func (c *Config) setEnvFromMap(data map[string]interface{}, prefix string) error
     return nil
 }
 
+// EnvOverride replaces values with the variables named after their keys,
+// read from the environment given to SetEnv or the process environment. See
+// EnvOverrideWithOptions for lists, maps and new keys.
 func (c *Config) EnvOverride(prefix string) {
-    c.envOverrideMap(c.data, prefix, "")
+    c.EnvOverrideWithOptions(EnvOverrideOptions{Prefix: prefix})
 }
 
-func (c *Config) envOverrideMap(data map[string]any, prefix string, parentKey string) {
-    for key := range data {
-        var envKey string
-        if parentKey == "" {
-            if prefix != "" {
-                envKey = prefix + "_" + key
-            } else {
-                envKey = key
-            }
-        } else {
-            envKey = parentKey + "_" + key
-        }
-
-        envKey = strings.ToUpper(envKey)
-
-        if envValue := os.Getenv(envKey); envValue != "" {
-            switch data[key].(type) {
-            case int:
-                var intVal int
-                if _, err := fmt.Sscanf(envValue, "%d", &intVal); err == nil {
-                    data[key] = intVal
-                }
-            case float64:
-                var floatVal float64
-                if _, err := fmt.Sscanf(envValue, "%f", &floatVal); err == nil {
-                    data[key] = floatVal
-                }
-            case bool:
-                switch strings.ToLower(envValue) {
-                case "true", "1", "yes", "on":
-                    data[key] = true
-                case "false", "0", "no", "off":
-                    data[key] = false
-                }
-            default:
-                data[key] = envValue
-            }
-        }
-
-        if nestedMap, ok := data[key].(map[string]any); ok {
-            c.envOverrideMap(nestedMap, prefix, envKey)
-        }
-    }
+func getenv(env Env, key string) string {
+    value, _ := env.LookupEnv(key)
+    return value
 }
 
 func parseInt(value string, lineNum, col int, line string) (int, error) {
@@ -0,0 +1,120 @@
+package dml
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// EnvCascadeOptions controls LoadEnvCascadeWithOptions.
+type EnvCascadeOptions struct {
+	// Env receives the variables; the process environment when nil.
+	Env MutableEnv
+	// Override replaces variables the environment already has. By default
+	// they are kept, so real deployment settings beat files.
+	Override bool
+}
+
+// EnvCascade reports what LoadEnvCascade did.
+type EnvCascade struct {
+	// Files lists the files that exist, lowest precedence first.
+	Files []string
+	// Sources maps every variable that was set to the file it came from.
+	Sources map[string]string
+	// Kept lists, sorted, the variables the files define but that were left
+	// alone because the environment already had them.
+	Kept []string
+}
+
+// Source returns the file that supplied name, or "" when no file set it.
+func (c *EnvCascade) Source(name string) string {
+	return c.Sources[name]
+}
+
+// EnvCascadeFiles returns the file names LoadEnvCascade reads for envName,
+// lowest precedence first: .env, .env.local, .env.<envName> and
+// .env.<envName>.local. As is conventional, .env.local is skipped for the
+// "test" environment so that tests do not depend on a developer's machine.
+func EnvCascadeFiles(envName string) []string {
+	files := []string{".env"}
+	if envName != "test" {
+		files = append(files, ".env.local")
+	}
+	if envName != "" {
+		files = append(files, ".env."+envName, ".env."+envName+".local")
+	}
+	return files
+}
+
+// LoadEnvCascade loads the .env files of dir for envName into the process
+// environment, later files overriding earlier ones, without replacing
+// variables that are already set. Missing files are skipped.
+func LoadEnvCascade(dir, envName string) (*EnvCascade, error) {
+	return LoadEnvCascadeWithOptions(dir, envName, EnvCascadeOptions{})
+}
+
+// LoadEnvCascadeWithOptions is LoadEnvCascade with a target environment and
+// override policy. References in a file see the variables that will win:
+// kept environment values first, then the file's own, then earlier files.
+func LoadEnvCascadeWithOptions(dir, envName string, opts EnvCascadeOptions) (*EnvCascade, error) {
+	env := opts.Env
+	if env == nil {
+		env = OSEnv()
+	}
+
+	vars := make(map[string]string)
+	sources := make(map[string]string)
+	// Kept environment values come before the file's own variables, which
+	// come before those of earlier files.
+	var kept func(string) (string, bool)
+	if !opts.Override {
+		kept = env.LookupEnv
+	}
+	lookup := func(name string) (string, bool) {
+		if v, ok := vars[name]; ok {
+			return v, true
+		}
+		return env.LookupEnv(name)
+	}
+
+	report := &EnvCascade{Sources: make(map[string]string)}
+	for _, name := range EnvCascadeFiles(envName) {
+		path := filepath.Join(dir, name)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to open .env file: %w", err)
+		}
+		fileVars, err := parseDotenv(string(data), kept, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		report.Files = append(report.Files, path)
+		for k, v := range fileVars {
+			vars[k] = v
+			sources[k] = path
+		}
+	}
+
+	names := make([]string, 0, len(vars))
+	for k := range vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		if _, set := env.LookupEnv(k); set && !opts.Override {
+			report.Kept = append(report.Kept, k)
+			continue
+		}
+		if err := env.Setenv(k, vars[k]); err != nil {
+			return nil, fmt.Errorf("failed to set env var %s: %w", k, err)
+		}
+		report.Sources[k] = sources[k]
+	}
+	return report, nil
+}
@@ -0,0 +1,119 @@
+package dml
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeEnvFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadEnvCascade(t *testing.T) {
+	dir := writeEnvFiles(t, map[string]string{
+		".env":                  "HOST=localhost\nPORT=5432\nNAME=app\nREGION=local\n",
+		".env.local":            "PORT=6000\n",
+		".env.production":       "HOST=db.prod\nURL=postgres://${HOST}:${PORT}/${NAME}\n",
+		".env.production.local": "NAME=app-override\n",
+		".env.staging":          "HOST=db.staging\n",
+	})
+	env := NewMapEnv(map[string]string{"REGION": "eu-west-1"})
+
+	report, err := LoadEnvCascadeWithOptions(dir, "production", EnvCascadeOptions{Env: env})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"HOST":   "db.prod",
+		"PORT":   "6000",
+		"NAME":   "app-override",
+		"URL":    "postgres://db.prod:6000/app",
+		"REGION": "eu-west-1",
+	}
+	if got := env.Vars(); !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected environment:\n got %v\nwant %v", got, want)
+	}
+
+	sources := map[string]string{
+		"HOST": ".env.production",
+		"PORT": ".env.local",
+		"NAME": ".env.production.local",
+		"URL":  ".env.production",
+	}
+	for name, file := range sources {
+		if got := report.Source(name); got != filepath.Join(dir, file) {
+			t.Errorf("Source(%s) = %q, want %s", name, got, file)
+		}
+	}
+	if !reflect.DeepEqual(report.Kept, []string{"REGION"}) {
+		t.Errorf("expected REGION to be kept, got %v", report.Kept)
+	}
+	if len(report.Files) != 4 {
+		t.Errorf("expected 4 files, got %v", report.Files)
+	}
+}
+
+func TestLoadEnvCascade_Override(t *testing.T) {
+	dir := writeEnvFiles(t, map[string]string{".env": "REGION=local\n"})
+	env := NewMapEnv(map[string]string{"REGION": "eu-west-1"})
+
+	report, err := LoadEnvCascadeWithOptions(dir, "", EnvCascadeOptions{Env: env, Override: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := env.LookupEnv("REGION"); v != "local" || len(report.Kept) != 0 {
+		t.Errorf("expected REGION to be overridden, got %q (kept %v)", v, report.Kept)
+	}
+}
+
+func TestLoadEnvCascade_SameFileReferences(t *testing.T) {
+	dir := writeEnvFiles(t, map[string]string{
+		".env": "HOST=localhost\nPORT=8080\nURL=http://${HOST}:${PORT}\n",
+	})
+
+	env := NewMapEnv(map[string]string{"HOST": "prod.example"})
+	if _, err := LoadEnvCascadeWithOptions(dir, "", EnvCascadeOptions{Env: env}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := env.LookupEnv("URL"); v != "http://prod.example:8080" {
+		t.Errorf("expected the kept HOST in URL, got %q", v)
+	}
+
+	env = NewMapEnv(map[string]string{"HOST": "prod.example"})
+	if _, err := LoadEnvCascadeWithOptions(dir, "", EnvCascadeOptions{Env: env, Override: true}); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := env.LookupEnv("URL"); v != "http://localhost:8080" {
+		t.Errorf("expected the file's HOST in URL with Override, got %q", v)
+	}
+}
+
+func TestEnvCascadeFiles(t *testing.T) {
+	tests := map[string][]string{
+		"":           {".env", ".env.local"},
+		"production": {".env", ".env.local", ".env.production", ".env.production.local"},
+		"test":       {".env", ".env.test", ".env.test.local"},
+	}
+	for name, want := range tests {
+		if got := EnvCascadeFiles(name); !reflect.DeepEqual(got, want) {
+			t.Errorf("EnvCascadeFiles(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestLoadEnvCascade_ParseError(t *testing.T) {
+	dir := writeEnvFiles(t, map[string]string{".env.local": "NOT VALID\n"})
+	if _, err := LoadEnvCascadeWithOptions(dir, "", EnvCascadeOptions{Env: NewMapEnv(nil)}); err == nil {
+		t.Error("expected a parse error")
+	}
+}
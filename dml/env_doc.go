@@ -0,0 +1,229 @@
+package dml
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// EnvVar is an environment variable a config understands: one that
+// EnvOverride reads for a key, one referenced with ${VAR}, or both.
+type EnvVar struct {
+	Name string `json:"name"`
+	// Keys are the dotted keys the variable overrides or is referenced by.
+	Keys []string `json:"keys"`
+	Type string   `json:"type"`
+	// Default is the value used when the variable is not set: the current
+	// value of an overridable key, or the word of ${VAR:-default}.
+	Default string `json:"default"`
+	// Required is set for ${VAR:?message} references.
+	Required    bool   `json:"required"`
+	Description string `json:"description,omitempty"`
+	// Override is set when EnvOverride reads the variable; Reference when a
+	// value refers to it.
+	Override  bool `json:"override"`
+	Reference bool `json:"reference"`
+}
+
+// EnvVars lists the environment variables the config understands, sorted by
+// name: the variables EnvOverride and SetEnvDefaults derive from every key
+// with prefix (APP_SERVER_PORT for server.port, APP_SERVER for the server map
+// itself), and every variable referenced
+// by interpolation. Call it before LoadWithEnv, while references are still in
+// place.
+func (c *Config) EnvVars(prefix string) []EnvVar {
+	vars := make(map[string]*EnvVar)
+	get := func(name, key string) *EnvVar {
+		v, ok := vars[name]
+		if !ok {
+			v = &EnvVar{Name: name}
+			vars[name] = v
+		}
+		for _, k := range v.Keys {
+			if k == key {
+				return v
+			}
+		}
+		v.Keys = append(v.Keys, key)
+		return v
+	}
+
+	var walk func(value any, key string)
+	walk = func(value any, key string) {
+		switch val := value.(type) {
+		case map[string]any:
+			for _, k := range c.sortedKeys(val) {
+				walk(val[k], joinKey(key, k))
+			}
+			if key == "" {
+				return
+			}
+		case []any:
+			for i, item := range val {
+				walk(item, fmt.Sprintf("%s[%d]", key, i))
+			}
+		case string:
+			for _, r := range envReferences(val) {
+				v := get(r.Name, key)
+				v.Reference = true
+				if v.Type == "" {
+					v.Type = "string"
+					if t, ok := c.types[key]; ok && holdsType(t, val) {
+						v.Type = t
+					}
+				}
+				switch r.Op {
+				case '-':
+					if v.Default == "" {
+						v.Default = r.Word
+					}
+				case '?':
+					v.Required = true
+					if v.Description == "" {
+						v.Description = r.Word
+					}
+				}
+			}
+		}
+		if strings.Contains(key, "[") {
+			return
+		}
+
+		v := get(envVarName(prefix, key), key)
+		v.Override = true
+		v.Type = dmlTypeOf(value)
+		if t, ok := c.types[key]; ok {
+			v.Type = t
+		}
+		if _, isMap := value.(map[string]any); isMap {
+			// EnvOverride replaces a whole map from a JSON object.
+			v.Type = "map (JSON object)"
+		}
+		if s, isString := value.(string); !isString || !hasEnvReference(s) {
+			v.Default = envDefault(value)
+		}
+		if v.Description == "" {
+			for _, d := range c.decls {
+				if d.Name == key && d.Doc != "" {
+					v.Description = d.Doc
+				}
+			}
+		}
+	}
+	walk(c.data, "")
+
+	out := make([]EnvVar, 0, len(vars))
+	for _, v := range vars {
+		out = append(out, *v)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// envDefault renders value the way EnvOverrideWithOptions reads it back:
+// lists of plain scalars comma-separated, other lists and maps as JSON.
+func envDefault(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case float64:
+		return formatDMLFloat(v)
+	case time.Duration:
+		return v.String()
+	case []any:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			switch item.(type) {
+			case map[string]any, []any:
+				return envJSON(v)
+			}
+			s := envDefault(item)
+			if strings.ContainsAny(s, `,"[]{}`) || s != strings.TrimSpace(s) {
+				return envJSON(v)
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ",")
+	case map[string]any:
+		return envJSON(v)
+	}
+	return fmt.Sprint(value)
+}
+
+func envJSON(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// WriteEnvMarkdown writes vars as a Markdown table.
+func WriteEnvMarkdown(w io.Writer, vars []EnvVar) error {
+	var sb strings.Builder
+	sb.WriteString("| Variable | Type | Required | Default | Keys | Description |\n")
+	sb.WriteString("| -------- | ---- | -------- | ------- | ---- | ----------- |\n")
+	for _, v := range vars {
+		required := "no"
+		if v.Required {
+			required = "yes"
+		}
+		keys := make([]string, len(v.Keys))
+		for i, k := range v.Keys {
+			keys[i] = markdownCode(k)
+		}
+		fmt.Fprintf(&sb, "| %s | %s | %s | %s | %s | %s |\n",
+			markdownCode(v.Name), v.Type, required, markdownCode(v.Default),
+			strings.Join(keys, ", "), markdownCell(v.Description))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func markdownCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	return "`" + markdownCell(s) + "`"
+}
+
+// WriteEnvExample writes vars as a .env.example template that ParseEnv reads
+// back: each variable with its default, after comments naming its keys and
+// type.
+func WriteEnvExample(w io.Writer, vars []EnvVar) error {
+	var sb strings.Builder
+	for i, v := range vars {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		for _, line := range strings.Split(v.Description, "\n") {
+			if line != "" {
+				fmt.Fprintf(&sb, "# %s\n", line)
+			}
+		}
+		fmt.Fprintf(&sb, "# %s (%s)", strings.Join(v.Keys, ", "), v.Type)
+		if v.Required {
+			sb.WriteString(", required")
+		}
+		fmt.Fprintf(&sb, "\n%s=%s\n", v.Name, dotenvQuote(v.Default))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+// dotenvQuote double-quotes s when ParseEnv would not read it back as is.
+func dotenvQuote(s string) string {
+	if !strings.ContainsAny(s, " \t\n\r#\"'`$\\") {
+		return s
+	}
+	r := strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "\n", `\n`, "\r", `\r`, "\t", `\t`)
+	return `"` + r.Replace(s) + `"`
+}
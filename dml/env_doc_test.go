@@ -0,0 +1,99 @@
+package dml
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+const envDocSource = `// HTTP port to listen on
+int server.port = 8080;
+list allowed_hosts = ["localhost", "example.com"];
+string db_url = "postgres://${DB_HOST:-localhost}/app";
+string db_password = "${DB_PASSWORD:?set it in the vault}";
+int workers = ${WORKERS:-4};
+list servers = [{"host": "${PRIMARY_HOST}", "note": "a b"}];
+`
+
+func envDocVars(t *testing.T) map[string]EnvVar {
+	t.Helper()
+	cfg := New()
+	if err := cfg.Parse(envDocSource); err != nil {
+		t.Fatal(err)
+	}
+	vars := make(map[string]EnvVar)
+	for _, v := range cfg.EnvVars("APP") {
+		vars[v.Name] = v
+	}
+	return vars
+}
+
+func TestConfig_EnvVars(t *testing.T) {
+	vars := envDocVars(t)
+
+	port := vars["APP_SERVER_PORT"]
+	if !port.Override || port.Type != "int" || port.Default != "8080" || port.Description != "HTTP port to listen on" {
+		t.Errorf("unexpected APP_SERVER_PORT %+v", port)
+	}
+	if hosts := vars["APP_ALLOWED_HOSTS"]; hosts.Default != "localhost,example.com" {
+		t.Errorf("expected a comma-separated default, got %q", hosts.Default)
+	}
+	if dbHost := vars["DB_HOST"]; !dbHost.Reference || dbHost.Override || dbHost.Default != "localhost" {
+		t.Errorf("unexpected DB_HOST %+v", dbHost)
+	}
+	if pw := vars["DB_PASSWORD"]; !pw.Required || pw.Description != "set it in the vault" {
+		t.Errorf("unexpected DB_PASSWORD %+v", pw)
+	}
+	if workers := vars["WORKERS"]; workers.Type != "int" || workers.Default != "4" {
+		t.Errorf("expected WORKERS to take the declared type, got %+v", workers)
+	}
+	if appWorkers := vars["APP_WORKERS"]; appWorkers.Default != "" {
+		t.Errorf("expected no default for a key holding a reference, got %q", appWorkers.Default)
+	}
+	if primary := vars["PRIMARY_HOST"]; len(primary.Keys) != 1 || primary.Keys[0] != "servers[0].host" {
+		t.Errorf("expected references inside lists of maps, got %+v", primary)
+	}
+	if _, ok := vars["APP_SERVERS_0_HOST"]; ok {
+		t.Error("list items have no override variable")
+	}
+	if server := vars["APP_SERVER"]; !server.Override || server.Type != "map (JSON object)" || server.Default != `{"port":8080}` {
+		t.Errorf("expected a JSON object variable for the server map, got %+v", server)
+	}
+}
+
+func TestWriteEnvExample_RoundTrip(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse(envDocSource); err != nil {
+		t.Fatal(err)
+	}
+	vars := cfg.EnvVars("APP")
+
+	var buf bytes.Buffer
+	if err := WriteEnvExample(&buf, vars); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := parseDotenv(buf.String(), nil, func(string) (string, bool) { return "", false })
+	if err != nil {
+		t.Fatalf("ParseEnv cannot read the template: %v\n%s", err, buf.String())
+	}
+	for _, v := range vars {
+		if got, ok := parsed[v.Name]; !ok || got != v.Default {
+			t.Errorf("%s = %q, want %q", v.Name, got, v.Default)
+		}
+	}
+	if !strings.Contains(buf.String(), "# db_password (string), required\nDB_PASSWORD=\n") {
+		t.Errorf("expected the required marker in:\n%s", buf.String())
+	}
+}
+
+func TestWriteEnvMarkdown(t *testing.T) {
+	vars := []EnvVar{{Name: "A", Keys: []string{"a"}, Type: "string", Default: "x|y", Required: true, Description: "two\nlines"}}
+	var buf bytes.Buffer
+	if err := WriteEnvMarkdown(&buf, vars); err != nil {
+		t.Fatal(err)
+	}
+	want := "| `A` | string | yes | `x\\|y` | `a` | two<br>lines |\n"
+	if !strings.HasSuffix(buf.String(), want) {
+		t.Errorf("unexpected table:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,296 @@
+package dml
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type EnvListFormat int
+
+const (
+	// EnvListAuto reads a JSON array when the value starts with '[' and a
+	// comma-separated list otherwise.
+	EnvListAuto EnvListFormat = iota
+	// EnvListComma splits the value on commas; items are read like DML
+	// literals, so 80,443 gives numbers and "a,b" quoted items keep commas out.
+	EnvListComma
+	// EnvListJSON requires a JSON array.
+	EnvListJSON
+)
+
+// EnvOverrideOptions controls EnvOverrideWithOptions. The zero value
+// overrides existing keys from unprefixed variables, reading lists in either
+// format.
+type EnvOverrideOptions struct {
+	// Prefix is prepended to every variable name, joined with '_'.
+	Prefix string
+	// Lists selects how variables for list keys are read.
+	Lists EnvListFormat
+	// CreateKeys adds keys for variables that start with Prefix but match no
+	// key. The rest of the name is split on '_' into nested keys, reusing
+	// existing maps: with Prefix "APP", APP_SERVER_TLS_CERT sets
+	// server.tls.cert. It needs a Prefix and an Env that implements
+	// EnvLister.
+	CreateKeys bool
+	// VarName returns the variable for a dotted key. By default it is the
+	// prefix and key joined with '_' and upper-cased: APP_SERVER_PORT for
+	// server.port.
+	VarName func(key string) string
+}
+
+// EnvAssignment is a variable considered by EnvOverrideWithOptions.
+type EnvAssignment struct {
+	Var    string
+	Key    string
+	Value  any    // the value set, for applied variables
+	Reason string // why the variable was not used, for ignored ones
+}
+
+// EnvOverrideReport lists the variables EnvOverrideWithOptions applied and
+// those it ignored, in the order it met them.
+type EnvOverrideReport struct {
+	Applied []EnvAssignment
+	Ignored []EnvAssignment
+}
+
+// EnvOverrideWithOptions replaces values with environment variables, like
+// EnvOverride. Variables for lists are parsed as opts.Lists says, variables
+// for maps must hold a JSON object, which replaces the map, and scalars are
+// parsed as the type of the value they replace. Variables that cannot be
+// parsed are reported as ignored and leave the value alone.
+func (c *Config) EnvOverrideWithOptions(opts EnvOverrideOptions) *EnvOverrideReport {
+	o := &envOverrider{
+		c:      c,
+		env:    c.environment(),
+		opts:   opts,
+		report: &EnvOverrideReport{},
+		seen:   make(map[string]bool),
+	}
+	o.walk(c.data, "")
+	o.unmatched()
+	return o.report
+}
+
+type envOverrider struct {
+	c      *Config
+	env    Env
+	opts   EnvOverrideOptions
+	report *EnvOverrideReport
+	seen   map[string]bool
+}
+
+func (o *envOverrider) varName(key string) string {
+	if o.opts.VarName != nil {
+		return o.opts.VarName(key)
+	}
+	return envVarName(o.opts.Prefix, key)
+}
+
+// envVarName is the variable EnvOverride and SetEnvDefaults use for a dotted
+// key: APP_SERVER_PORT for server.port with prefix APP.
+func envVarName(prefix, key string) string {
+	name := strings.ReplaceAll(key, ".", "_")
+	if prefix != "" {
+		name = prefix + "_" + name
+	}
+	return strings.ToUpper(name)
+}
+
+func (o *envOverrider) applied(name, key string, value any) {
+	o.report.Applied = append(o.report.Applied, EnvAssignment{Var: name, Key: key, Value: value})
+}
+
+func (o *envOverrider) ignored(name, key, reason string) {
+	o.report.Ignored = append(o.report.Ignored, EnvAssignment{Var: name, Key: key, Reason: reason})
+}
+
+func (o *envOverrider) walk(data map[string]any, prefix string) {
+	for _, key := range o.c.sortedKeys(data) {
+		path := joinKey(prefix, key)
+		name := o.varName(path)
+		o.seen[name] = true
+		if raw := getenv(o.env, name); raw != "" {
+			if value, err := o.convert(path, data[key], raw); err != nil {
+				o.ignored(name, path, err.Error())
+			} else {
+				data[key] = value
+				o.applied(name, path, value)
+			}
+		}
+		if nested, ok := data[key].(map[string]any); ok {
+			o.walk(nested, path)
+		}
+	}
+}
+
+// convert parses raw as a replacement for current, the value of key.
+func (o *envOverrider) convert(key string, current any, raw string) (any, error) {
+	switch current.(type) {
+	case []any:
+		return o.parseList(key, raw)
+	case map[string]any:
+		v, err := o.parseJSON(key, raw)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.(map[string]any); !ok {
+			return nil, fmt.Errorf("%s is a map; the variable must hold a JSON object", key)
+		}
+		return v, nil
+	case string:
+		if declared, ok := o.c.types[key]; ok {
+			return o.scalar(declared, raw)
+		}
+		return raw, nil
+	case int, float64, bool, time.Duration:
+		return o.scalar(dmlTypeOf(current), raw)
+	}
+	return raw, nil
+}
+
+func (o *envOverrider) scalar(typeName, raw string) (any, error) {
+	v, err := parseEnvScalar(typeName, raw)
+	if err != nil {
+		return nil, fmt.Errorf("%q is not a valid %s", raw, typeName)
+	}
+	return v, nil
+}
+
+func (o *envOverrider) parseList(key, raw string) (any, error) {
+	format := o.opts.Lists
+	if format == EnvListAuto {
+		format = EnvListComma
+		if strings.HasPrefix(strings.TrimSpace(raw), "[") {
+			format = EnvListJSON
+		}
+	}
+	if format == EnvListJSON {
+		v, err := o.parseJSON(key, raw)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := v.([]any); !ok {
+			return nil, fmt.Errorf("%s is a list; the variable must hold a JSON array", key)
+		}
+		return v, nil
+	}
+
+	items := o.c.smartSplit(raw, ',')
+	list := make([]any, 0, len(items))
+	for _, item := range items {
+		list = append(list, ParseValue(strings.TrimSpace(item)))
+	}
+	return list, nil
+}
+
+func (o *envOverrider) parseJSON(key, raw string) (any, error) {
+	dec := json.NewDecoder(strings.NewReader(raw))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("invalid JSON: %v", err)
+	}
+	value, _, err := o.c.fromJSONValue(key, v)
+	return value, err
+}
+
+// unmatched handles the variables with the prefix that no key claimed:
+// with CreateKeys they become keys, otherwise they are reported as ignored.
+func (o *envOverrider) unmatched() {
+	lister, ok := o.env.(EnvLister)
+	if !ok || o.opts.Prefix == "" {
+		return
+	}
+	prefix := strings.ToUpper(o.opts.Prefix) + "_"
+	for _, name := range lister.EnvNames() {
+		if o.seen[name] || !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		raw := getenv(o.env, name)
+		if raw == "" {
+			continue
+		}
+		segments := strings.Split(strings.ToLower(name[len(prefix):]), "_")
+		if !o.opts.CreateKeys {
+			o.ignored(name, strings.Join(segments, "."), "no matching key")
+			continue
+		}
+		key, value, err := o.place(o.c.data, "", segments, raw)
+		if err != nil {
+			o.ignored(name, key, err.Error())
+			continue
+		}
+		if !strings.Contains(key, ".") {
+			o.c.setType(key, dmlTypeOf(value))
+		}
+		o.applied(name, key, value)
+	}
+}
+
+// place sets the value of a variable split into segments below data. Each
+// existing key, which may itself contain '_', is reused before new maps are
+// made from the remaining segments.
+func (o *envOverrider) place(data map[string]any, prefix string, segments []string, raw string) (string, any, error) {
+	for j := len(segments); j >= 1; j-- {
+		key, ok := findKeyFold(data, strings.Join(segments[:j], "_"))
+		if !ok {
+			continue
+		}
+		path := joinKey(prefix, key)
+		if j == len(segments) {
+			value, err := o.convert(path, data[key], raw)
+			if err == nil {
+				data[key] = value
+			}
+			return path, value, err
+		}
+		nested, isMap := data[key].(map[string]any)
+		if !isMap {
+			return path, nil, fmt.Errorf("%s is a %s, not a map", path, dmlTypeOf(data[key]))
+		}
+		return o.place(nested, path, segments[j:], raw)
+	}
+
+	for _, s := range segments {
+		if !isValidIdentifier(s) {
+			return joinKey(prefix, strings.Join(segments, ".")), nil, fmt.Errorf("%q is not a valid key", s)
+		}
+	}
+	last := len(segments) - 1
+	for _, s := range segments[:last] {
+		child := make(map[string]any)
+		data[s] = child
+		data = child
+		prefix = joinKey(prefix, s)
+	}
+	path := joinKey(prefix, segments[last])
+	value := o.parseNew(path, raw)
+	data[segments[last]] = value
+	return path, value, nil
+}
+
+// parseNew reads the value of a new key: JSON when it is valid JSON array or
+// object, otherwise a DML literal.
+func (o *envOverrider) parseNew(key, raw string) any {
+	trimmed := strings.TrimSpace(raw)
+	if strings.HasPrefix(trimmed, "[") || strings.HasPrefix(trimmed, "{") {
+		if v, err := o.parseJSON(key, trimmed); err == nil {
+			return v
+		}
+	}
+	return ParseValue(trimmed)
+}
+
+func findKeyFold(data map[string]any, name string) (string, bool) {
+	if _, ok := data[name]; ok {
+		return name, true
+	}
+	for k := range data {
+		if strings.EqualFold(k, name) {
+			return k, true
+		}
+	}
+	return "", false
+}
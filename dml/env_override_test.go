@@ -0,0 +1,128 @@
+package dml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func overrideConfig(t *testing.T, vars map[string]string) *Config {
+	t.Helper()
+	cfg := New()
+	src := `list allowed_hosts = ["localhost"];
+list ports = [80];
+map server = {"port": 8080, "tls": {"enabled": false}};
+map limits = {"rps": 10};
+int workers = 4;
+`
+	if err := cfg.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+	cfg.SetEnv(NewMapEnv(vars))
+	return cfg
+}
+
+func TestEnvOverrideWithOptions_Lists(t *testing.T) {
+	cfg := overrideConfig(t, map[string]string{
+		"APP_ALLOWED_HOSTS": "a.example.com, b.example.com",
+		"APP_PORTS":         "[443, 8443]",
+	})
+	report := cfg.EnvOverrideWithOptions(EnvOverrideOptions{Prefix: "APP"})
+
+	if got := cfg.GetList("allowed_hosts"); !reflect.DeepEqual(got, []any{"a.example.com", "b.example.com"}) {
+		t.Errorf("unexpected allowed_hosts %v", got)
+	}
+	if got := cfg.GetList("ports"); !reflect.DeepEqual(got, []any{443, 8443}) {
+		t.Errorf("unexpected ports %v", got)
+	}
+	if len(report.Applied) != 2 || len(report.Ignored) != 0 {
+		t.Errorf("unexpected report %+v", report)
+	}
+
+	cfg = overrideConfig(t, map[string]string{"APP_PORTS": "443,8443"})
+	report = cfg.EnvOverrideWithOptions(EnvOverrideOptions{Prefix: "APP", Lists: EnvListJSON})
+	if len(report.Ignored) != 1 || report.Ignored[0].Key != "ports" {
+		t.Errorf("expected a comma list to be rejected in JSON mode, got %+v", report)
+	}
+	if got := cfg.GetList("ports"); !reflect.DeepEqual(got, []any{80}) {
+		t.Errorf("expected ports unchanged, got %v", got)
+	}
+}
+
+func TestEnvOverrideWithOptions_MapsAndErrors(t *testing.T) {
+	cfg := overrideConfig(t, map[string]string{
+		"APP_LIMITS":             `{"rps": 100, "burst": 20}`,
+		"APP_SERVER_TLS_ENABLED": "yes",
+		"APP_WORKERS":            "many",
+		"APP_UNKNOWN":            "1",
+	})
+	report := cfg.EnvOverrideWithOptions(EnvOverrideOptions{Prefix: "APP"})
+
+	if cfg.GetInt("limits.rps") != 100 || cfg.GetInt("limits.burst") != 20 {
+		t.Errorf("expected the JSON object to replace limits, got %v", cfg.GetMap("limits"))
+	}
+	if !cfg.GetBool("server.tls.enabled") {
+		t.Error("expected server.tls.enabled=true")
+	}
+	if cfg.GetInt("workers") != 4 {
+		t.Errorf("expected workers unchanged, got %d", cfg.GetInt("workers"))
+	}
+
+	ignored := map[string]string{}
+	for _, a := range report.Ignored {
+		ignored[a.Var] = a.Reason
+	}
+	if !strings.Contains(ignored["APP_WORKERS"], "not a valid int") {
+		t.Errorf("expected APP_WORKERS to be ignored as invalid, got %q", ignored["APP_WORKERS"])
+	}
+	if ignored["APP_UNKNOWN"] != "no matching key" {
+		t.Errorf("expected APP_UNKNOWN to be ignored, got %q", ignored["APP_UNKNOWN"])
+	}
+}
+
+func TestEnvOverrideWithOptions_CreateKeys(t *testing.T) {
+	cfg := overrideConfig(t, map[string]string{
+		"APP_SERVER_TLS_CERT": "/etc/cert.pem",
+		"APP_CACHE_TTL":       "30",
+		"APP_FEATURES":        `["a", "b"]`,
+		"APP_WORKERS_MAX":     "8",
+	})
+	report := cfg.EnvOverrideWithOptions(EnvOverrideOptions{Prefix: "APP", CreateKeys: true})
+
+	if cfg.GetString("server.tls.cert") != "/etc/cert.pem" {
+		t.Errorf("expected server.tls.cert to be created, got %v", cfg.GetMap("server"))
+	}
+	if cfg.GetInt("server.port") != 8080 {
+		t.Error("expected existing keys of server to stay")
+	}
+	if cfg.GetInt("cache.ttl") != 30 {
+		t.Errorf("expected cache.ttl=30, got %v", cfg.data["cache"])
+	}
+	if got := cfg.GetList("features"); !reflect.DeepEqual(got, []any{"a", "b"}) {
+		t.Errorf("unexpected features %v", got)
+	}
+	if typ, _ := cfg.DeclaredType("features"); typ != "list" {
+		t.Errorf("expected features to be typed list, got %q", typ)
+	}
+	if len(report.Ignored) != 1 || report.Ignored[0].Var != "APP_WORKERS_MAX" {
+		t.Errorf("expected APP_WORKERS_MAX to be ignored below a scalar, got %+v", report.Ignored)
+	}
+	if len(report.Applied) != 3 {
+		t.Errorf("expected 3 applied variables, got %+v", report.Applied)
+	}
+}
+
+func TestEnvOverrideWithOptions_VarName(t *testing.T) {
+	cfg := overrideConfig(t, map[string]string{"SERVICE__SERVER__PORT": "9090"})
+	report := cfg.EnvOverrideWithOptions(EnvOverrideOptions{
+		VarName: func(key string) string {
+			return "SERVICE__" + strings.ToUpper(strings.ReplaceAll(key, ".", "__"))
+		},
+	})
+	if cfg.GetInt("server.port") != 9090 {
+		t.Errorf("expected server.port=9090, got %d", cfg.GetInt("server.port"))
+	}
+	if len(report.Applied) != 1 || report.Applied[0].Key != "server.port" || report.Applied[0].Value != 9090 {
+		t.Errorf("unexpected report %+v", report)
+	}
+}
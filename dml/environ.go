@@ -0,0 +1,188 @@
+package dml
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Env is a source of environment variables. A Config reads the process
+// environment unless it is given another Env with SetEnv, so tests and
+// libraries can work with variables of their own.
+type Env interface {
+	LookupEnv(key string) (string, bool)
+}
+
+// MutableEnv is an Env that can also be written, as SetEnvDefaults needs.
+type MutableEnv interface {
+	Env
+	Setenv(key, value string) error
+}
+
+// EnvLister is an Env that can list the names of its variables, as
+// EnvOverrideWithOptions needs to find variables that match no key.
+type EnvLister interface {
+	Env
+	EnvNames() []string
+}
+
+// OSEnv returns the process environment as an Env.
+func OSEnv() MutableEnv {
+	return osEnv{}
+}
+
+type osEnv struct{}
+
+func (osEnv) LookupEnv(key string) (string, bool) { return os.LookupEnv(key) }
+
+func (osEnv) Setenv(key, value string) error { return os.Setenv(key, value) }
+
+func (osEnv) EnvNames() []string {
+	var names []string
+	for _, kv := range os.Environ() {
+		if name, _, ok := strings.Cut(kv, "="); ok && name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MapEnv is an environment held in memory. It is safe for concurrent use.
+type MapEnv struct {
+	mu   sync.RWMutex
+	vars map[string]string
+}
+
+// NewMapEnv returns an environment holding a copy of vars.
+func NewMapEnv(vars map[string]string) *MapEnv {
+	e := &MapEnv{vars: make(map[string]string, len(vars))}
+	for k, v := range vars {
+		e.vars[k] = v
+	}
+	return e
+}
+
+func (e *MapEnv) LookupEnv(key string) (string, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	v, ok := e.vars[key]
+	return v, ok
+}
+
+func (e *MapEnv) Setenv(key, value string) error {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.vars == nil {
+		e.vars = make(map[string]string)
+	}
+	e.vars[key] = value
+	return nil
+}
+
+// EnvNames returns the names of the variables, sorted.
+func (e *MapEnv) EnvNames() []string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	names := make([]string, 0, len(e.vars))
+	for k := range e.vars {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Vars returns a copy of the variables.
+func (e *MapEnv) Vars() map[string]string {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	out := make(map[string]string, len(e.vars))
+	for k, v := range e.vars {
+		out[k] = v
+	}
+	return out
+}
+
+// ReadEnvFile parses a .env file into a new MapEnv, leaving the process
+// environment alone. References in the file resolve against variables
+// defined earlier in the file, then env; a nil env resolves them against the
+// file only.
+func ReadEnvFile(filepath string, env Env) (*MapEnv, error) {
+	data, err := os.ReadFile(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open .env file: %w", err)
+	}
+
+	lookup := func(string) (string, bool) { return "", false }
+	if env != nil {
+		lookup = env.LookupEnv
+	}
+	vars, err := parseDotenv(string(data), nil, lookup)
+	if err != nil {
+		return nil, err
+	}
+	return &MapEnv{vars: vars}, nil
+}
+
+// LayeredEnv looks a variable up in each layer in turn, so earlier layers
+// take precedence. Writes go to the first layer that is a MutableEnv.
+func LayeredEnv(layers ...Env) MutableEnv {
+	return layeredEnv(layers)
+}
+
+type layeredEnv []Env
+
+func (l layeredEnv) LookupEnv(key string) (string, bool) {
+	for _, layer := range l {
+		if v, ok := layer.LookupEnv(key); ok {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// EnvNames returns the names known to the layers that can list them, sorted.
+func (l layeredEnv) EnvNames() []string {
+	seen := make(map[string]bool)
+	var names []string
+	for _, layer := range l {
+		lister, ok := layer.(EnvLister)
+		if !ok {
+			continue
+		}
+		for _, name := range lister.EnvNames() {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (l layeredEnv) Setenv(key, value string) error {
+	for _, layer := range l {
+		if m, ok := layer.(MutableEnv); ok {
+			return m.Setenv(key, value)
+		}
+	}
+	return fmt.Errorf("cannot set %s: no layer of the environment is writable", key)
+}
+
+// SetEnv makes the config read environment variables from env in
+// LoadWithEnv and EnvOverride, and write them there in SetEnvDefaults. A nil
+// env restores the process environment.
+func (c *Config) SetEnv(env Env) {
+	c.env = env
+}
+
+// environment returns the Env set with SetEnv, or the process environment.
+func (c *Config) environment() Env {
+	if c.env == nil {
+		return OSEnv()
+	}
+	return c.env
+}
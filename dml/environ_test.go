@@ -0,0 +1,152 @@
+package dml
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestConfig_SetEnv(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse("string host = \"${DML_ENV_HOST:-localhost}\";\nint port = ${DML_ENV_PORT};\nbool debug = false;\n"); err != nil {
+		t.Fatal(err)
+	}
+	cfg.SetEnv(NewMapEnv(map[string]string{
+		"DML_ENV_HOST": "db.internal",
+		"DML_ENV_PORT": "5433",
+		"APP_DEBUG":    "true",
+	}))
+
+	if err := cfg.LoadWithEnv(); err != nil {
+		t.Fatal(err)
+	}
+	cfg.EnvOverride("APP")
+
+	if cfg.GetString("host") != "db.internal" || cfg.GetInt("port") != 5433 || !cfg.GetBool("debug") {
+		t.Errorf("unexpected values %v", cfg.data)
+	}
+	if _, set := os.LookupEnv("DML_ENV_HOST"); set {
+		t.Error("SetEnv must not touch the process environment")
+	}
+}
+
+func TestConfig_SetEnvDefaults_MapEnv(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse("int port = 8080;\nstring name = \"app\";\n"); err != nil {
+		t.Fatal(err)
+	}
+	env := NewMapEnv(map[string]string{"SRV_NAME": "kept"})
+	cfg.SetEnv(env)
+
+	if err := cfg.SetEnvDefaults("SRV"); err != nil {
+		t.Fatal(err)
+	}
+	vars := env.Vars()
+	if vars["SRV_PORT"] != "8080" || vars["SRV_NAME"] != "kept" {
+		t.Errorf("unexpected environment %v", vars)
+	}
+	if _, set := os.LookupEnv("SRV_PORT"); set {
+		t.Error("SetEnvDefaults must write to the configured environment")
+	}
+
+	cfg.SetEnv(readOnlyEnv{})
+	if err := cfg.SetEnvDefaults("SRV"); err == nil {
+		t.Error("expected an error for a read-only environment")
+	}
+}
+
+func TestConfig_SetEnvDefaults_FloatsMatchEnvVars(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse("float ratio = 0.1;\nfloat tiny = 0.0000001;\nfloat whole = 2.0;\n"); err != nil {
+		t.Fatal(err)
+	}
+	env := NewMapEnv(nil)
+	cfg.SetEnv(env)
+	if err := cfg.SetEnvDefaults("APP"); err != nil {
+		t.Fatal(err)
+	}
+
+	vars := env.Vars()
+	for _, v := range cfg.EnvVars("APP") {
+		if vars[v.Name] != v.Default {
+			t.Errorf("%s: SetEnvDefaults set %q, EnvVars documents %q", v.Name, vars[v.Name], v.Default)
+		}
+	}
+	if vars["APP_RATIO"] != "0.1" {
+		t.Errorf("expected APP_RATIO=0.1, got %q", vars["APP_RATIO"])
+	}
+}
+
+type readOnlyEnv struct{}
+
+func (readOnlyEnv) LookupEnv(string) (string, bool) { return "", false }
+
+func TestLayeredEnv(t *testing.T) {
+	top := NewMapEnv(map[string]string{"A": "top"})
+	bottom := NewMapEnv(map[string]string{"A": "bottom", "B": "bottom"})
+	env := LayeredEnv(readOnlyEnv{}, top, bottom)
+
+	if v, _ := env.LookupEnv("A"); v != "top" {
+		t.Errorf("expected the first layer to win, got %q", v)
+	}
+	if v, _ := env.LookupEnv("B"); v != "bottom" {
+		t.Errorf("expected a fallback to later layers, got %q", v)
+	}
+	if _, ok := env.LookupEnv("C"); ok {
+		t.Error("expected C to be unset")
+	}
+
+	if err := env.Setenv("C", "new"); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := top.LookupEnv("C"); v != "new" {
+		t.Error("expected writes to go to the first writable layer")
+	}
+	if err := LayeredEnv(readOnlyEnv{}).Setenv("C", "x"); err == nil {
+		t.Error("expected an error when no layer is writable")
+	}
+}
+
+func TestReadEnvFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("DML_READ_ONLY=1\nURL=http://${DML_READ_ONLY}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	env, err := ReadEnvFile(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := env.LookupEnv("URL"); v != "http://1" {
+		t.Errorf("expected URL=http://1, got %q", v)
+	}
+	if _, set := os.LookupEnv("DML_READ_ONLY"); set {
+		t.Error("ReadEnvFile must not touch the process environment")
+	}
+	if _, err := ReadEnvFile(filepath.Join(t.TempDir(), "missing.env"), nil); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
+
+func TestReadEnvFile_ResolvesAgainstGivenEnv(t *testing.T) {
+	t.Setenv("DML_DB_HOST", "process-host")
+	path := filepath.Join(t.TempDir(), ".env")
+	if err := os.WriteFile(path, []byte("DB_URL=postgres://${DML_DB_HOST}/app\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	env, err := ReadEnvFile(path, NewMapEnv(map[string]string{"DML_DB_HOST": "isolated-host"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := env.LookupEnv("DB_URL"); v != "postgres://isolated-host/app" {
+		t.Errorf("expected the isolated env to win, got %q", v)
+	}
+
+	env, err = ReadEnvFile(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := env.LookupEnv("DB_URL"); v != "postgres:///app" {
+		t.Errorf("expected the process env to be ignored, got %q", v)
+	}
+}
@@ -8,9 +8,14 @@ import (
 type DMLError struct {
     Line    int
     Column  int
+    // Code identifies the kind of error independently of the language of
+    // Message, e.g. "MISSING_SEMICOLON".
+    Code    string
     Message string
     Context string
     Type    ErrorType
+
+    locale string
 }
 
 type ErrorType int
@@ -35,10 +40,28 @@ func (t ErrorType) String() string {
     }
 }
 
+func (t ErrorType) messageCode() string {
+    switch t {
+    case ErrorTypeSyntax:
+        return "ERROR_SYNTAX"
+    case ErrorTypeValidation:
+        return "ERROR_VALIDATION"
+    case ErrorTypeType:
+        return "ERROR_TYPE"
+    default:
+        return "ERROR_UNKNOWN"
+    }
+}
+
 func (e *DMLError) Error() string {
     var sb strings.Builder
     
-    sb.WriteString(fmt.Sprintf("%s at line %d:%d\n", e.Type, e.Line, e.Column))
+    locale := e.locale
+    if locale == "" {
+        locale = Locale()
+    }
+    sb.WriteString(localize(locale, "ERROR_HEADER", localize(locale, e.Type.messageCode()), e.Line, e.Column))
+    sb.WriteString("\n")
     sb.WriteString(fmt.Sprintf("  %s\n", e.Message))
     
     if e.Context != "" {
@@ -0,0 +1,196 @@
+package dml
+
+import (
+	"sort"
+	"strings"
+)
+
+type FormatOptions struct {
+	// SortKeys orders the entries of map literals alphabetically.
+	SortKeys bool
+}
+
+// Format returns src in canonical layout: no indentation at the top level,
+// single spaces around '=' and after ':' and ',', exactly one trailing
+// semicolon, at most one blank line between statements, and maps laid out
+// according to the file's @mapStyle directive. Comments are kept.
+func Format(src []byte) ([]byte, error) {
+	return FormatWithOptions(src, FormatOptions{})
+}
+
+func FormatWithOptions(src []byte, opts FormatOptions) ([]byte, error) {
+	f := scanCST(string(src))
+	fm := &formatter{f: f, opts: opts, style: MapStyleAuto}
+
+	for _, n := range f.nodes {
+		if n.kind == cstDirective {
+			fields := strings.Fields(f.src[n.start:n.end])
+			if len(fields) >= 2 && fields[0] == "@mapStyle" {
+				switch strings.ToLower(fields[1]) {
+				case "json":
+					fm.style = MapStyleJSON
+				case "flat":
+					fm.style = MapStyleFlat
+				}
+			}
+		}
+	}
+
+	var out []string
+	blank := false
+	for _, n := range f.nodes {
+		if n.kind == cstBlank {
+			blank = len(out) > 0
+			continue
+		}
+		if blank {
+			out = append(out, "")
+			blank = false
+		}
+		out = append(out, fm.node(n)...)
+	}
+
+	result := strings.Join(out, "\n")
+	if result != "" {
+		result += "\n"
+	}
+
+	if err := New().Parse(result); err != nil {
+		return nil, err
+	}
+	return []byte(result), nil
+}
+
+type formatter struct {
+	f     *cstFile
+	opts  FormatOptions
+	style MapStyle
+}
+
+func (fm *formatter) node(n *cstNode) []string {
+	f := fm.f
+	raw := strings.TrimSpace(f.src[n.start:n.end])
+
+	switch n.kind {
+	case cstComment:
+		return []string{raw}
+	case cstDirective:
+		return []string{strings.Join(strings.Fields(raw), " ")}
+	}
+
+	if n.eq < 0 || !n.typ.valid() || !n.name.valid() || n.unclosed {
+		return []string{raw}
+	}
+
+	typ := f.text(n.typ)
+	name := f.text(n.name)
+	value := n.value
+	for value.valid() && f.src[value.end-1] == ';' {
+		value = trimSpan(f.src, span{value.start, value.end - 1})
+	}
+
+	if typ == "map" && fm.style == MapStyleFlat {
+		if lines, ok := fm.flatten(name, value); ok {
+			return lines
+		}
+	}
+
+	multiLine := typ == "map" && (fm.style == MapStyleJSON ||
+		(fm.style == MapStyleAuto && strings.Contains(f.text(value), "\n")))
+
+	if multiLine && f.src[value.start] == '{' {
+		entries := fm.sortedEntries(value)
+		if len(entries) == 0 {
+			return []string{typ + " " + name + " = {};"}
+		}
+		lines := []string{typ + " " + name + " = {"}
+		for i, e := range entries {
+			line := "  " + fm.entry(e)
+			if i < len(entries)-1 {
+				line += ","
+			}
+			lines = append(lines, line)
+		}
+		return append(lines, "};")
+	}
+
+	return []string{typ + " " + name + " = " + fm.value(value) + ";"}
+}
+
+// flatten expands a map literal into dotted declarations for @mapStyle flat.
+func (fm *formatter) flatten(prefix string, s span) ([]string, bool) {
+	f := fm.f
+	if !s.valid() || f.src[s.start] != '{' {
+		return nil, false
+	}
+
+	entries := fm.sortedEntries(s)
+	if len(entries) == 0 {
+		return []string{"map " + prefix + " = {};"}, true
+	}
+
+	var lines []string
+	for _, e := range entries {
+		if !e.key.valid() || !e.value.valid() {
+			return nil, false
+		}
+		key := prefix + "." + e.name
+		if !isValidIdentifier(key) {
+			return nil, false
+		}
+		if f.src[e.value.start] == '{' && len(f.entries(e.value)) > 0 {
+			nested, ok := fm.flatten(key, e.value)
+			if !ok {
+				return nil, false
+			}
+			lines = append(lines, nested...)
+			continue
+		}
+		typ := dmlTypeOf((&Config{}).parseMapValue(f.text(e.value)))
+		lines = append(lines, typ+" "+key+" = "+fm.value(e.value)+";")
+	}
+	return lines, true
+}
+
+func (fm *formatter) sortedEntries(s span) []cstEntry {
+	entries := fm.f.entries(s)
+	if fm.opts.SortKeys && fm.f.src[s.start] == '{' {
+		sort.SliceStable(entries, func(i, j int) bool { return entries[i].name < entries[j].name })
+	}
+	return entries
+}
+
+func (fm *formatter) entry(e cstEntry) string {
+	if !e.key.valid() {
+		return strings.Join(strings.Fields(fm.f.text(span{e.start, e.end})), " ")
+	}
+	return `"` + e.name + `": ` + fm.value(e.value)
+}
+
+func (fm *formatter) value(s span) string {
+	f := fm.f
+	if !s.valid() {
+		return ""
+	}
+
+	switch f.src[s.start] {
+	case '{', '[':
+		entries := fm.sortedEntries(s)
+		if entries == nil && f.src[s.end-1] != '}' && f.src[s.end-1] != ']' {
+			return f.text(s)
+		}
+		parts := make([]string, len(entries))
+		for i, e := range entries {
+			if f.src[s.start] == '{' {
+				parts[i] = fm.entry(e)
+			} else {
+				parts[i] = fm.value(e.value)
+			}
+		}
+		if f.src[s.start] == '{' {
+			return "{" + strings.Join(parts, ", ") + "}"
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return f.text(s)
+}
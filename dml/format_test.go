@@ -0,0 +1,65 @@
+package dml
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFormat_Golden(t *testing.T) {
+	inputs, err := filepath.Glob("../testdata/fmt/*.input")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(inputs) == 0 {
+		t.Fatal("no golden inputs found")
+	}
+
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".input")
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, err := os.ReadFile(strings.TrimSuffix(input, ".input") + ".golden")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			opts := FormatOptions{SortKeys: strings.HasPrefix(name, "sorted")}
+			got, err := FormatWithOptions(src, opts)
+			if err != nil {
+				t.Fatalf("Format: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Format mismatch\n--- got ---\n%s\n--- want ---\n%s", got, want)
+			}
+
+			again, err := FormatWithOptions(got, opts)
+			if err != nil {
+				t.Fatalf("Format (second pass): %v", err)
+			}
+			if string(again) != string(got) {
+				t.Errorf("Format is not idempotent\n--- first ---\n%s\n--- second ---\n%s", got, again)
+			}
+
+			before, after := New(), New()
+			if err := before.Parse(string(src)); err == nil {
+				if err := after.Parse(string(got)); err != nil {
+					t.Fatalf("formatted output does not parse: %v", err)
+				}
+				if before.Dump() != after.Dump() {
+					t.Errorf("formatting changed the meaning of the file")
+				}
+			}
+		})
+	}
+}
+
+func TestFormat_InvalidSource(t *testing.T) {
+	if _, err := Format([]byte("string name = ;\nint = 4;\n")); err == nil {
+		t.Fatal("expected error for invalid source")
+	}
+}
@@ -0,0 +1,278 @@
+package dml
+
+import (
+	"errors"
+	"strings"
+)
+
+// Interpolation follows the shell:
+//
+//	${VAR}            value of VAR, empty when unset
+//	$VAR              same, for simple names
+//	${VAR:-default}   default when VAR is unset or empty (${VAR-default}: unset only)
+//	${VAR:?message}   error when VAR is unset or empty (${VAR?message}: unset only)
+//	${VAR:+alt}       alt when VAR is set and not empty (${VAR+alt}: set)
+//	$$                a literal $
+//
+// Words after the operator are expanded too, so defaults may refer to other
+// variables. References that do not parse are kept as written.
+
+// EnvError reports a ${VAR:?message} reference to a variable that is not set.
+type EnvError struct {
+	Var     string
+	Message string
+}
+
+func (e *EnvError) Error() string {
+	if e.Message == "" {
+		return e.Var + ": required variable is not set"
+	}
+	return e.Var + ": " + e.Message
+}
+
+// Interpolate expands variable references in s, looking values up with
+// lookup (os.LookupEnv for the process environment). It fails with an
+// *EnvError when a ${VAR:?} reference is not satisfied; the returned string
+// still has every other reference expanded.
+func Interpolate(s string, lookup func(string) (string, bool)) (string, error) {
+	if !strings.Contains(s, "$") {
+		return s, nil
+	}
+	var sb strings.Builder
+	var firstErr error
+	for i := 0; i < len(s); {
+		if s[i] != '$' || i+1 == len(s) {
+			sb.WriteByte(s[i])
+			i++
+			continue
+		}
+		switch next := s[i+1]; {
+		case next == '$':
+			sb.WriteByte('$')
+			i += 2
+		case next == '{':
+			end := matchingBrace(s, i+1)
+			if end < 0 {
+				sb.WriteString(s[i:])
+				i = len(s)
+				continue
+			}
+			value, ok, err := expandReference(s[i+2:end], lookup)
+			if !ok {
+				value = s[i : end+1]
+			}
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			sb.WriteString(value)
+			i = end + 1
+		case isNameStart(next):
+			j := i + 1
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			value, _ := lookup(s[i+1 : j])
+			sb.WriteString(value)
+			i = j
+		default:
+			sb.WriteByte('$')
+			i++
+		}
+	}
+	return sb.String(), firstErr
+}
+
+// hasEnvReference reports whether s refers to at least one variable.
+func hasEnvReference(s string) bool {
+	found := false
+	Interpolate(s, func(string) (string, bool) {
+		found = true
+		return "", false
+	})
+	return found
+}
+
+// envTemplate returns the text of a declaration value that refers to the
+// environment, so that scalar types other than string can be filled in by
+// LoadWithEnv. Both `${PORT}` and `"${PORT}"` are accepted.
+func envTemplate(varType, value string) (string, bool) {
+	switch varType {
+	case "string", "int", "number", "float", "bool", "boolean", "duration":
+	default:
+		return "", false
+	}
+	if len(value) >= 2 && strings.HasPrefix(value, `"`) && strings.HasSuffix(value, `"`) {
+		value = value[1 : len(value)-1]
+	}
+	if !hasEnvReference(value) {
+		return "", false
+	}
+	return value, true
+}
+
+// envReference is a parsed ${...} reference: ${Name}, or ${Name<op>Word}
+// where op is -, ? or +, with Colon set for the :-, :? and :+ forms.
+type envReference struct {
+	Name  string
+	Colon bool
+	Op    byte
+	Word  string
+}
+
+// parseReference parses the inside of ${...}.
+func parseReference(ref string) (envReference, bool) {
+	n := 0
+	for n < len(ref) && (isNameChar(ref[n]) && (n > 0 || isNameStart(ref[n]))) {
+		n++
+	}
+	if n == 0 {
+		return envReference{}, false
+	}
+	r := envReference{Name: ref[:n]}
+	rest := ref[n:]
+	if rest == "" {
+		return r, true
+	}
+	if strings.HasPrefix(rest, ":") {
+		r.Colon = true
+		rest = rest[1:]
+	}
+	if rest == "" || strings.IndexByte("-?+", rest[0]) < 0 {
+		return envReference{}, false
+	}
+	r.Op, r.Word = rest[0], rest[1:]
+	return r, true
+}
+
+// envReferences lists the references in s in order, including those nested
+// in defaults.
+func envReferences(s string) []envReference {
+	var refs []envReference
+	for i := 0; i+1 < len(s); i++ {
+		if s[i] != '$' {
+			continue
+		}
+		switch next := s[i+1]; {
+		case next == '$':
+			i++
+		case next == '{':
+			end := matchingBrace(s, i+1)
+			if end < 0 {
+				return refs
+			}
+			if r, ok := parseReference(s[i+2 : end]); ok {
+				refs = append(refs, r)
+				refs = append(refs, envReferences(r.Word)...)
+			}
+			i = end
+		case isNameStart(next):
+			j := i + 1
+			for j < len(s) && isNameChar(s[j]) {
+				j++
+			}
+			refs = append(refs, envReference{Name: s[i+1 : j]})
+			i = j - 1
+		}
+	}
+	return refs
+}
+
+// expandReference expands the inside of ${...}. ok is false when the text is
+// not a valid reference.
+func expandReference(ref string, lookup func(string) (string, bool)) (string, bool, error) {
+	r, ok := parseReference(ref)
+	if !ok {
+		return "", false, nil
+	}
+	value, set := lookup(r.Name)
+	present := set && (!r.Colon || value != "")
+
+	switch r.Op {
+	case 0:
+		return value, true, nil
+	case '-':
+		if present {
+			return value, true, nil
+		}
+		w, err := Interpolate(r.Word, lookup)
+		return w, true, err
+	case '+':
+		if !present {
+			return "", true, nil
+		}
+		w, err := Interpolate(r.Word, lookup)
+		return w, true, err
+	}
+	if present {
+		return value, true, nil
+	}
+	msg, err := Interpolate(r.Word, lookup)
+	if err != nil {
+		return "", true, err
+	}
+	return "", true, &EnvError{Var: r.Name, Message: msg}
+}
+
+// matchingBrace returns the index of the '}' closing the '{' at open,
+// allowing nested ${...} references.
+func matchingBrace(s string, open int) int {
+	depth := 0
+	for i := open; i < len(s); i++ {
+		switch s[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func isNameStart(b byte) bool {
+	return b == '_' || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+func isNameChar(b byte) bool {
+	return isNameStart(b) || '0' <= b && b <= '9'
+}
+
+// envError turns an interpolation failure for key into a DMLError positioned
+// at the declaration that holds key.
+func (c *Config) envError(key string, err error) error {
+	var envErr *EnvError
+	if !errors.As(err, &envErr) {
+		return err
+	}
+	line, col := 0, 0
+	if d, ok := c.declarationFor(key); ok {
+		line, col = d.Line, d.Column
+	}
+	msg := envErr.Message
+	if msg == "" {
+		msg = localize(c.messageLocale(), "ENV_NOT_SET")
+	}
+	return c.newError(ErrorTypeValidation, "ENV_REQUIRED", line, col, "", envErr.Var, key, msg)
+}
+
+// declarationFor finds the declaration that sets key: the declaration of key
+// itself, or of the map or list that contains it.
+func (c *Config) declarationFor(key string) (Declaration, bool) {
+	if i := strings.IndexByte(key, '['); i >= 0 {
+		key = key[:i]
+	}
+	for {
+		for i := len(c.decls) - 1; i >= 0; i-- {
+			if c.decls[i].Name == key {
+				return c.decls[i], true
+			}
+		}
+		dot := strings.LastIndexByte(key, '.')
+		if dot < 0 {
+			return Declaration{}, false
+		}
+		key = key[:dot]
+	}
+}
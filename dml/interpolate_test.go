@@ -0,0 +1,181 @@
+package dml
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestInterpolate(t *testing.T) {
+	env := map[string]string{"HOST": "example.com", "PORT": "8080", "EMPTY": ""}
+	lookup := func(k string) (string, bool) {
+		v, ok := env[k]
+		return v, ok
+	}
+
+	tests := []struct {
+		in, want string
+	}{
+		{"${HOST}:$PORT", "example.com:8080"},
+		{"${MISSING}", ""},
+		{"${MISSING:-fallback}", "fallback"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${EMPTY-fallback}", ""},
+		{"${MISSING-fallback}", "fallback"},
+		{"${HOST:-fallback}", "example.com"},
+		{"${MISSING:-${HOST}:${PORT:-80}}", "example.com:8080"},
+		{"${HOST:+https}", "https"},
+		{"${EMPTY:+https}", ""},
+		{"${EMPTY+https}", "https"},
+		{"${MISSING:+https}", ""},
+		{"costs $$5", "costs $5"},
+		{"$$HOST", "$HOST"},
+		{"${1BAD}", "${1BAD}"},
+		{"${HOST:}", "${HOST:}"},
+		{"${HOST", "${HOST"},
+		{"price: 5$", "price: 5$"},
+	}
+	for _, tt := range tests {
+		got, err := Interpolate(tt.in, lookup)
+		if err != nil {
+			t.Errorf("Interpolate(%q) error: %v", tt.in, err)
+		}
+		if got != tt.want {
+			t.Errorf("Interpolate(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestInterpolate_Required(t *testing.T) {
+	lookup := func(k string) (string, bool) {
+		if k == "EMPTY" {
+			return "", true
+		}
+		return "", false
+	}
+
+	got, err := Interpolate("${HOST}/${TOKEN:?set TOKEN first}", lookup)
+	var envErr *EnvError
+	if !errors.As(err, &envErr) {
+		t.Fatalf("expected *EnvError, got %v", err)
+	}
+	if envErr.Var != "TOKEN" || envErr.Message != "set TOKEN first" {
+		t.Errorf("unexpected error %+v", envErr)
+	}
+	if got != "/" {
+		t.Errorf("expected the other references expanded, got %q", got)
+	}
+
+	if _, err := Interpolate("${EMPTY:?}", lookup); err == nil {
+		t.Error("expected ${EMPTY:?} to fail for an empty variable")
+	}
+	if _, err := Interpolate("${EMPTY?}", lookup); err != nil {
+		t.Errorf("expected ${EMPTY?} to accept an empty variable, got %v", err)
+	}
+}
+
+func TestLoadWithEnv_Required(t *testing.T) {
+	t.Setenv("DML_TEST_HOST", "db.local")
+
+	cfg := New()
+	src := "string host = \"${DML_TEST_HOST}\";\n" +
+		"map db = {\n" +
+		"  \"password\": \"${DML_TEST_MISSING_PASSWORD:?}\"\n" +
+		"};\n"
+	if err := cfg.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+
+	err := cfg.LoadWithEnv()
+	var dmlErr *DMLError
+	if !errors.As(err, &dmlErr) {
+		t.Fatalf("expected *DMLError, got %v", err)
+	}
+	if dmlErr.Code != "ENV_REQUIRED" || dmlErr.Line != 2 {
+		t.Errorf("expected ENV_REQUIRED on line 2, got %s on line %d", dmlErr.Code, dmlErr.Line)
+	}
+	if cfg.GetString("host") != "${DML_TEST_HOST}" {
+		t.Errorf("expected config unchanged on error, got host=%q", cfg.GetString("host"))
+	}
+
+	t.Setenv("DML_TEST_MISSING_PASSWORD", "hunter2")
+	if err := cfg.LoadWithEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GetString("host") != "db.local" || cfg.GetString("db.password") != "hunter2" {
+		t.Errorf("unexpected values %v", cfg.data)
+	}
+}
+
+func TestLoadWithEnv_Typed(t *testing.T) {
+	t.Setenv("DML_TEST_PORT", "6543")
+	t.Setenv("DML_TEST_DEBUG", "yes")
+	t.Setenv("DML_TEST_HOST", "db.local")
+
+	src := `int db.port = ${DML_TEST_PORT};
+bool debug = "${DML_TEST_DEBUG}";
+float ratio = ${DML_TEST_RATIO:-0.5};
+duration timeout = ${DML_TEST_TIMEOUT:-30s};
+string name = ${DML_TEST_NAME:-app};
+list servers = [{"host": "${DML_TEST_HOST}", "tags": ["${DML_TEST_HOST}"]}];
+`
+	cfg := New()
+	if err := cfg.Parse(src); err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.GetString("db.port"); got != "${DML_TEST_PORT}" {
+		t.Errorf("expected the template before expansion, got %q", got)
+	}
+
+	// The declared types survive a Dump/Parse round trip before expansion.
+	again := New()
+	if err := again.Parse(cfg.Dump()); err != nil {
+		t.Fatalf("Parse(Dump()) failed: %v\n%s", err, cfg.Dump())
+	}
+	if typ, _ := again.DeclaredType("debug"); typ != "bool" {
+		t.Errorf("expected debug to stay bool, got %q", typ)
+	}
+
+	if err := cfg.LoadWithEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.GetInt("db.port") != 6543 {
+		t.Errorf("expected db.port=6543, got %v", cfg.data["db"])
+	}
+	if !cfg.GetBool("debug") {
+		t.Error("expected debug=true")
+	}
+	if cfg.GetFloat("ratio") != 0.5 {
+		t.Errorf("expected ratio=0.5, got %v", cfg.GetFloat("ratio"))
+	}
+	if cfg.GetDuration("timeout") != 30*time.Second {
+		t.Errorf("expected timeout=30s, got %v", cfg.GetDuration("timeout"))
+	}
+	if cfg.GetString("name") != "app" {
+		t.Errorf("expected name=app, got %q", cfg.GetString("name"))
+	}
+	server := cfg.GetList("servers")[0].(map[string]any)
+	if server["host"] != "db.local" || server["tags"].([]any)[0] != "db.local" {
+		t.Errorf("expected lists of maps to be expanded, got %v", server)
+	}
+}
+
+func TestLoadWithEnv_TypeError(t *testing.T) {
+	t.Setenv("DML_TEST_PORT", "not-a-port")
+
+	cfg := New()
+	if err := cfg.Parse("string host = \"x\";\nint port = ${DML_TEST_PORT};\n"); err != nil {
+		t.Fatal(err)
+	}
+	err := cfg.LoadWithEnv()
+	var dmlErr *DMLError
+	if !errors.As(err, &dmlErr) {
+		t.Fatalf("expected *DMLError, got %v", err)
+	}
+	if dmlErr.Type != ErrorTypeType || dmlErr.Code != "ENV_INVALID_VALUE" || dmlErr.Line != 2 {
+		t.Errorf("expected a type error on line 2, got %s %s on line %d", dmlErr.Type, dmlErr.Code, dmlErr.Line)
+	}
+	if cfg.GetString("port") != "${DML_TEST_PORT}" {
+		t.Errorf("expected config unchanged on error, got %q", cfg.GetString("port"))
+	}
+}
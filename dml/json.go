@@ -0,0 +1,148 @@
+package dml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type JSONMode int
+
+const (
+	// JSONReplace discards the current data and loads the document in its place.
+	JSONReplace JSONMode = iota
+	// JSONMerge deep-merges the document into the current data. Maps are merged
+	// key by key; lists and scalars from the document win.
+	JSONMerge
+)
+
+// LoadJSON loads a JSON object into the config. Integral numbers become int
+// and fractional ones float64, unless the key already has a declared type, in
+// which case the value is converted to that type.
+func (c *Config) LoadJSON(data []byte, mode JSONMode) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	converted := make(map[string]any, len(raw))
+	for k, v := range raw {
+		val, ok, err := c.fromJSONValue(k, v)
+		if err != nil {
+			return err
+		}
+		if ok {
+			converted[k] = val
+		}
+	}
+
+	switch mode {
+	case JSONReplace:
+		c.data = converted
+	case JSONMerge:
+		mergeMaps(c.data, converted)
+	default:
+		return fmt.Errorf("unknown JSON mode %d", mode)
+	}
+
+	c.inferTypes()
+	return nil
+}
+
+func (c *Config) fromJSONValue(key string, value any) (any, bool, error) {
+	declared := canonicalType(c.types[key])
+
+	switch v := value.(type) {
+	case nil:
+		return nil, false, nil
+	case json.Number:
+		return jsonNumber(key, v, declared)
+	case string:
+		if declared == "duration" {
+			d, err := time.ParseDuration(v)
+			if err != nil {
+				return nil, false, fmt.Errorf("key '%s': invalid duration %q", key, v)
+			}
+			return d, true, nil
+		}
+		return v, true, nil
+	case []any:
+		list := make([]any, 0, len(v))
+		for i, item := range v {
+			val, ok, err := c.fromJSONValue(fmt.Sprintf("%s[%d]", key, i), item)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				list = append(list, val)
+			}
+		}
+		return list, true, nil
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, item := range v {
+			val, ok, err := c.fromJSONValue(key+"."+k, item)
+			if err != nil {
+				return nil, false, err
+			}
+			if ok {
+				m[k] = val
+			}
+		}
+		return m, true, nil
+	}
+	return value, true, nil
+}
+
+func jsonNumber(key string, n json.Number, declared string) (any, bool, error) {
+	text := n.String()
+
+	switch declared {
+	case "float":
+		f, err := n.Float64()
+		return f, err == nil, err
+	case "duration":
+		i, err := n.Int64()
+		if err != nil {
+			return nil, false, fmt.Errorf("key '%s': invalid duration %s", key, text)
+		}
+		return time.Duration(i), true, nil
+	}
+
+	if !strings.ContainsAny(text, ".eE") {
+		if i, err := strconv.Atoi(text); err == nil {
+			return i, true, nil
+		}
+	}
+
+	f, err := n.Float64()
+	if err != nil {
+		return nil, false, err
+	}
+	if declared == "int" {
+		if f != math.Trunc(f) || math.Abs(f) > math.MaxInt64 {
+			return nil, false, fmt.Errorf("key '%s': invalid int %s", key, text)
+		}
+		return int(f), true, nil
+	}
+	return f, true, nil
+}
+
+func mergeMaps(dst, src map[string]any) {
+	for k, v := range src {
+		if srcMap, ok := v.(map[string]any); ok {
+			if dstMap, ok := dst[k].(map[string]any); ok {
+				mergeMaps(dstMap, srcMap)
+				continue
+			}
+		}
+		dst[k] = v
+	}
+}
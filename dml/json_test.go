@@ -0,0 +1,178 @@
+package dml
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestFromJSON_RestoresInts(t *testing.T) {
+	cfg := New()
+	if err := cfg.FromJSON(`{"port": 8080, "ratio": 0.5, "server": {"timeout": 30}, "ports": [80, 443]}`); err != nil {
+		t.Fatalf("FromJSON: %v", err)
+	}
+
+	if v, _ := cfg.Get("port"); v != 8080 {
+		t.Errorf("expected port int 8080, got %#v", v)
+	}
+	if v, _ := cfg.Get("ratio"); v != 0.5 {
+		t.Errorf("expected ratio float 0.5, got %#v", v)
+	}
+	if v, _ := cfg.Get("server.timeout"); v != 30 {
+		t.Errorf("expected server.timeout int 30, got %#v", v)
+	}
+	if v := cfg.GetList("ports"); v[0] != 80 {
+		t.Errorf("expected ports[0] int 80, got %#v", v[0])
+	}
+	if typ, _ := cfg.DeclaredType("port"); typ != "int" {
+		t.Errorf("expected inferred type int, got %q", typ)
+	}
+}
+
+func TestFromJSON_RoundTripKeepsStrictTypes(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse("int port = 8080;\nstring host = \"localhost\";"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	out, err := cfg.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+
+	back := New()
+	if err := back.FromJSON(out); err != nil {
+		t.Fatalf("FromJSON: %v", err)
+	}
+
+	if err := back.applyDefault("port", 9090, DefaultPolicy{Override: true, StrictTypes: true}); err != nil {
+		t.Errorf("StrictTypes should accept int default after round trip: %v", err)
+	}
+}
+
+func TestLoadJSON_UsesDeclaredTypes(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse("float ratio = 0.5;\nduration grace = \"10s\";\nint workers = 4;"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	if err := cfg.LoadJSON([]byte(`{"ratio": 1, "grace": "30s", "workers": 8}`), JSONReplace); err != nil {
+		t.Fatalf("LoadJSON: %v", err)
+	}
+
+	if v, _ := cfg.Get("ratio"); v != 1.0 {
+		t.Errorf("expected declared float to stay float64, got %#v", v)
+	}
+	if v, _ := cfg.Get("grace"); v != 30*time.Second {
+		t.Errorf("expected declared duration 30s, got %#v", v)
+	}
+	if typ, _ := cfg.DeclaredType("ratio"); typ != "float" {
+		t.Errorf("expected ratio type float, got %q", typ)
+	}
+}
+
+func TestToJSON_DurationRoundTrip(t *testing.T) {
+	src := `duration grace = "30s";
+map server = {"timeout": "1m30s"};`
+	cfg := New()
+	if err := cfg.Parse(src); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	cfg.Set("server.timeout", 90*time.Second)
+
+	out, err := cfg.ToJSON()
+	if err != nil {
+		t.Fatalf("ToJSON: %v", err)
+	}
+	if !strings.Contains(out, `"grace": "30s"`) || !strings.Contains(out, `"timeout": "1m30s"`) {
+		t.Fatalf("expected durations as text, got:\n%s", out)
+	}
+
+	back := New()
+	if err := back.Parse(src); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := back.LoadJSON([]byte(out), JSONReplace); err != nil {
+		t.Fatalf("LoadJSON: %v", err)
+	}
+	if v, _ := back.Get("grace"); v != 30*time.Second {
+		t.Errorf("expected grace to come back as 30s, got %#v", v)
+	}
+}
+
+func TestLoadJSON_InvalidDeclaredDuration(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse(`duration grace = "10s";`); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := cfg.LoadJSON([]byte(`{"grace": "soon"}`), JSONReplace); err == nil {
+		t.Fatal("expected error for invalid duration")
+	}
+}
+
+func TestLoadJSON_FractionalDeclaredInt(t *testing.T) {
+	cfg := New()
+	if err := cfg.Parse("int workers = 4;"); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	err := cfg.LoadJSON([]byte(`{"workers": 1.5}`), JSONReplace)
+	if err == nil {
+		t.Fatal("expected error for fractional value of an int key")
+	}
+	if !strings.Contains(err.Error(), "workers") {
+		t.Errorf("expected error to name the key, got %v", err)
+	}
+
+	if err := cfg.LoadJSON([]byte(`{"workers": 2.0}`), JSONReplace); err != nil {
+		t.Fatalf("LoadJSON: %v", err)
+	}
+	if v, _ := cfg.Get("workers"); v != 2 {
+		t.Errorf("expected integral float to load as int 2, got %#v", v)
+	}
+}
+
+func TestLoadJSON_ReplaceAndMerge(t *testing.T) {
+	base := `map server = {"host": "localhost", "port": 8080};
+string name = "api";`
+
+	replaced := New()
+	if err := replaced.Parse(base); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := replaced.LoadJSON([]byte(`{"server": {"port": 9090}}`), JSONReplace); err != nil {
+		t.Fatalf("LoadJSON replace: %v", err)
+	}
+	if replaced.Has("name") || replaced.Has("server.host") {
+		t.Errorf("replace mode should drop keys missing from the document")
+	}
+	if _, ok := replaced.DeclaredType("name"); ok {
+		t.Errorf("replace mode should drop types of removed keys")
+	}
+
+	merged := New()
+	if err := merged.Parse(base); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if err := merged.LoadJSON([]byte(`{"server": {"port": 9090}, "debug": true}`), JSONMerge); err != nil {
+		t.Fatalf("LoadJSON merge: %v", err)
+	}
+	if merged.GetString("name") != "api" || merged.GetString("server.host") != "localhost" {
+		t.Errorf("merge mode should keep existing keys")
+	}
+	if v, _ := merged.Get("server.port"); v != 9090 {
+		t.Errorf("expected merged server.port int 9090, got %#v", v)
+	}
+	if !merged.GetBool("debug") {
+		t.Errorf("expected debug=true to be merged in")
+	}
+}
+
+func TestFromJSON_DropsNulls(t *testing.T) {
+	cfg := New()
+	if err := cfg.FromJSON(`{"a": null, "b": 1}`); err != nil {
+		t.Fatalf("FromJSON: %v", err)
+	}
+	if cfg.Has("a") {
+		t.Error("expected null to be dropped")
+	}
+}
@@ -1,11 +1,12 @@
 package dml
 
 import (
-	"bufio"
 	"fmt"
 	"os"
-	"regexp"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
 )
 
 type LintIssue struct {
@@ -13,151 +14,312 @@ type LintIssue struct {
 	Code    string
 	Message string
 	Line    int
+	Column  int
+	// EndLine and EndColumn mark the exclusive end of the reported range.
+	// Rules may leave them zero; the engine then sets them to Line and
+	// Column.
+	EndLine   int
+	EndColumn int
+	// Fix is set when the issue has a mechanical fix; see ApplyFixes.
+	Fix *SuggestedFix
 }
 
+const (
+	LintLevelError   = "error"
+	LintLevelWarning = "warning"
+	LintLevelOff     = "off"
+)
+
+// LintRule is a single check run by Lint. Check reports issues with at least
+// Line and Message set; Code and Level are filled in by the engine from the
+// rule and the active configuration.
+type LintRule interface {
+	Code() string
+	DefaultLevel() string
+	Description() string
+	Check(ctx *LintContext) []LintIssue
+}
+
+// LintContext is the input handed to every rule. Built-in rules work on the
+// same concrete syntax tree the editor and formatter use, so positions match
+// those reported by Parse.
+type LintContext struct {
+	Source []byte
+	Lines  []string
+	// Config is the parsed file, or nil when the file does not parse.
+	Config *Config
+	// Options holds the settings from .dmllint, so rules can read their own
+	// parameters. It is never nil.
+	Options *Config
+
+	file     *cstFile
+	parseErr error
+	locale   string
+}
+
+// Message returns the text registered for code in the run's locale, so
+// custom rules can ship translations through RegisterMessages.
+func (ctx *LintContext) Message(code string, args ...any) string {
+	return localize(ctx.locale, code, args...)
+}
+
+type funcRule struct {
+	code, level, description string
+	check                    func(ctx *LintContext) []LintIssue
+}
+
+func (r *funcRule) Code() string                       { return r.code }
+func (r *funcRule) DefaultLevel() string               { return r.level }
+func (r *funcRule) Description() string                { return r.description }
+func (r *funcRule) Check(ctx *LintContext) []LintIssue { return r.check(ctx) }
+
+// NewLintRule builds a LintRule from a function, for rules that need no
+// state of their own.
+func NewLintRule(code, level, description string, check func(ctx *LintContext) []LintIssue) LintRule {
+	return &funcRule{code: code, level: level, description: description, check: check}
+}
+
+var (
+	lintRules   = make(map[string]LintRule)
+	lintRulesMu sync.RWMutex
+)
+
+// RegisterLintRule adds rule to the set run by Lint, replacing any rule with
+// the same code.
+func RegisterLintRule(rule LintRule) {
+	lintRulesMu.Lock()
+	defer lintRulesMu.Unlock()
+	lintRules[rule.Code()] = rule
+}
+
+// LintRules returns the registered rules sorted by code.
+func LintRules() []LintRule {
+	lintRulesMu.RLock()
+	defer lintRulesMu.RUnlock()
+	rules := make([]LintRule, 0, len(lintRules))
+	for _, r := range lintRules {
+		rules = append(rules, r)
+	}
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Code() < rules[j].Code() })
+	return rules
+}
+
+// LintOptions configures a lint run.
+type LintOptions struct {
+	// Rules overrides the level of rules by code: "error", "warning" or
+	// "off". "on" restores a rule's default level.
+	Rules map[string]string
+	// Settings carries rule parameters, usually loaded from .dmllint.
+	Settings *Config
+	// Locale selects the language of messages; empty means the locale set
+	// with SetLocale.
+	Locale string
+}
+
+// LintConfigFile is the name of the file Lint looks for next to the linted
+// file and in its parent directories.
+const LintConfigFile = ".dmllint"
+
+// LoadLintConfig reads a .dmllint file. It is itself a DML file:
+//
+//	map rules = {"EMPTY_MAP": "off", "MIXED_MAP_STYLE": "error"};
+//
+// An optional `string locale = "pl";` sets the message language and
+// `string naming = "snake_case";` (or "camelCase") enables KEY_NAMING. Other
+// keys are kept in Settings for rules that take parameters.
+func LoadLintConfig(path string) (LintOptions, error) {
+	cfg, err := NewConfig(path)
+	if err != nil {
+		return LintOptions{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	opts := LintOptions{Rules: make(map[string]string), Settings: cfg, Locale: cfg.GetString("locale")}
+	for code, v := range cfg.GetMap("rules") {
+		level, ok := v.(string)
+		if !ok {
+			return LintOptions{}, fmt.Errorf("%s: rule %s: level must be a string", path, code)
+		}
+		level = strings.ToLower(level)
+		switch level {
+		case LintLevelError, LintLevelWarning, LintLevelOff, "on":
+		default:
+			return LintOptions{}, fmt.Errorf("%s: rule %s: unknown level %q", path, code, level)
+		}
+		opts.Rules[code] = level
+	}
+	if naming := cfg.GetString("naming"); naming != "" {
+		if _, ok := keyNamingStyles[naming]; !ok {
+			return LintOptions{}, fmt.Errorf("%s: unknown naming style %q (want snake_case or camelCase)", path, naming)
+		}
+	}
+	return opts, nil
+}
+
+// FindLintConfig returns the nearest .dmllint in dir or one of its parents.
+func FindLintConfig(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	for {
+		candidate := filepath.Join(dir, LintConfigFile)
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, true
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+	}
+}
+
+// Lint checks the file at path with the registered rules, using the nearest
+// .dmllint file if there is one.
 func Lint(path string) ([]LintIssue, error) {
-	f, err := os.Open(path)
+	var opts LintOptions
+	if cfgPath, ok := FindLintConfig(filepath.Dir(path)); ok {
+		var err error
+		if opts, err = LoadLintConfig(cfgPath); err != nil {
+			return nil, err
+		}
+	}
+	return LintWithOptions(path, opts)
+}
+
+func LintWithOptions(path string, opts LintOptions) ([]LintIssue, error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
-	defer f.Close()
+	return LintSourceWithOptions(content, opts)
+}
+
+// LintSource lints in-memory content with default rule levels.
+func LintSource(src []byte) ([]LintIssue, error) {
+	return LintSourceWithOptions(src, LintOptions{})
+}
 
-	scanner := bufio.NewScanner(f)
-	var lines []string
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+func LintSourceWithOptions(src []byte, opts LintOptions) ([]LintIssue, error) {
+	text := strings.ReplaceAll(string(src), "\r\n", "\n")
+	ctx := &LintContext{
+		Source:  src,
+		Lines:   strings.Split(strings.TrimSuffix(text, "\n"), "\n"),
+		Options: opts.Settings,
+		file:    scanCST(string(src)),
+		locale:  opts.Locale,
 	}
-	if err := scanner.Err(); err != nil {
-		return nil, err
+	if ctx.locale == "" {
+		ctx.locale = Locale()
 	}
-	content := strings.Join(lines, "\n")
-	var issues []LintIssue
+	if ctx.Options == nil {
+		ctx.Options = New()
+	}
+	cfg := New()
+	cfg.locale = ctx.locale
+	if ctx.parseErr = cfg.Parse(text); ctx.parseErr == nil {
+		ctx.Config = cfg
+	}
+
+	suppressed := lintSuppressions(ctx.Lines)
 
-	mapOpenRe := regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)\s*=\s*\{\s*$`)
-	typedEntryRe := regexp.MustCompile(`^\s*[A-Za-z_][A-Za-z0-9_]*\s+[A-Za-z_][A-Za-z0-9_]*\s*=`)
-	rootAssignRe := regexp.MustCompile(`^\s*([A-Za-z_][A-Za-z0-9_]*)\s*=\s*(.*)$`)
-	defaultRe := regexp.MustCompile(`^\s*default\s+([A-Za-z_][A-Za-z0-9_]*)\s*=`)
-
-	n := len(lines)
-	i := 0
-	rootVars := 0
-	mapCount := 0
-	defaultNames := make(map[string]int)
-
-	for i < n {
-		line := lines[i]
-
-		if m := defaultRe.FindStringSubmatch(line); m != nil {
-			name := m[1]
-			defaultNames[name] = i + 1
-		}
-
-		if mo := mapOpenRe.FindStringSubmatch(line); mo != nil {
-			mapCount++
-			mapName := mo[1]
-			openLine := i + 1
-			depth := 1
-			j := i + 1
-			for j < n && depth > 0 {
-				l := lines[j]
-				depth += strings.Count(l, "{")
-				depth -= strings.Count(l, "}")
-				j++
+	var issues []LintIssue
+	for _, rule := range LintRules() {
+		level := rule.DefaultLevel()
+		if override, ok := opts.Rules[rule.Code()]; ok && override != "on" {
+			level = override
+		}
+		if level == LintLevelOff {
+			continue
+		}
+		for _, it := range rule.Check(ctx) {
+			if it.Code == "" {
+				it.Code = rule.Code()
 			}
-			if depth != 0 {
-				issues = append(issues, LintIssue{
-					Level:   "error",
-					Code:    "MAP_UNCLOSED",
-					Message: fmt.Sprintf("Mapa %q niezamknięta", mapName),
-					Line:    openLine,
-				})
-				i++
-				continue
+			if it.EndLine == 0 {
+				it.EndLine, it.EndColumn = it.Line, it.Column
 			}
-			closeIdx := j - 1
-			empty := true
-			firstEntryIdx := -1
-			for k := i + 1; k < closeIdx; k++ {
-				s := strings.TrimSpace(lines[k])
-				if s == "" || strings.HasPrefix(s, "#") || strings.HasPrefix(s, "//") {
-					continue
-				}
-				empty = false
-				if firstEntryIdx == -1 {
-					firstEntryIdx = k
-				}
-				if typedEntryRe.MatchString(lines[k]) {
-					issues = append(issues, LintIssue{
-						Level:   "error",
-						Code:    "TYPED_MAP_ENTRY",
-						Message: "Typed entries w mapie (np. 'string port = ...') - unikaj typowanych wpisów w mapach",
-						Line:    k + 1,
-					})
-				}
+			if it.Level == "" || level != rule.DefaultLevel() {
+				it.Level = level
 			}
-			if empty {
-				issues = append(issues, LintIssue{
-					Level:   "warning",
-					Code:    "EMPTY_MAP",
-					Message: fmt.Sprintf("Pusta mapa %q", mapName),
-					Line:    openLine,
-				})
-			} else {
-				lastIdx := -1
-				for k := closeIdx - 1; k > i; k-- {
-					s := strings.TrimSpace(lines[k])
-					if s == "" || strings.HasPrefix(s, "#") || strings.HasPrefix(s, "//") {
-						continue
-					}
-					lastIdx = k
-					break
-				}
-				if lastIdx != -1 {
-					if strings.HasSuffix(strings.TrimRight(lines[lastIdx], " \t"), ",") {
-						issues = append(issues, LintIssue{
-							Level:   "error",
-							Code:    "MAP_TRAILING_COMMA",
-							Message: fmt.Sprintf("Przecinek po ostatnim elemencie mapy %q", mapName),
-							Line:    lastIdx + 1,
-						})
-					}
-				}
+			if suppressed.covers(it.Line, it.Code) {
+				continue
 			}
-			i = closeIdx + 1
-			continue
+			issues = append(issues, it)
 		}
+	}
 
-		if m := rootAssignRe.FindStringSubmatch(line); m != nil {
-			rhs := strings.TrimSpace(m[2])
-			if rhs == "" || !strings.HasPrefix(rhs, "{") {
-				rootVars++
-			}
+	sort.SliceStable(issues, func(i, j int) bool {
+		if issues[i].Line != issues[j].Line {
+			return issues[i].Line < issues[j].Line
+		}
+		if issues[i].Column != issues[j].Column {
+			return issues[i].Column < issues[j].Column
 		}
+		return issues[i].Code < issues[j].Code
+	})
+	return issues, nil
+}
 
-		i++
-	}
+// suppressions maps a line number to the codes silenced on it; an empty set
+// silences every rule. Line 0 holds file-wide suppressions.
+type suppressions map[int]map[string]bool
 
-	if rootVars > 0 && mapCount > 0 {
-		issues = append(issues, LintIssue{
-			Level:   "warning",
-			Code:    "MIXED_MAP_STYLE",
-			Message: "Mieszany styl: użyto map i zmiennych root (map + root vars) — rozważ ujednolicenie",
-			Line:    1,
-		})
+func (s suppressions) covers(line int, code string) bool {
+	for _, l := range []int{0, line} {
+		if codes, ok := s[l]; ok && (len(codes) == 0 || codes[code]) {
+			return true
+		}
 	}
+	return false
+}
 
-	for name, lineNum := range defaultNames {
-		occRe := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b\s*=`)
-		matches := occRe.FindAllStringIndex(content, -1)
-		if len(matches) <= 1 {
-			issues = append(issues, LintIssue{
-				Level:   "warning",
-				Code:    "UNUSED_DEFAULT",
-				Message: fmt.Sprintf("Nieużyty default %q", name),
-				Line:    lineNum,
-			})
+// lintSuppressions reads comment directives:
+//
+//	// dml-lint-disable-next-line EMPTY_MAP, MAP_TRAILING_COMMA
+//	// dml-lint-disable-file UNUSED_DEFAULT
+//
+// Without codes, every rule is silenced.
+func lintSuppressions(lines []string) suppressions {
+	s := make(suppressions)
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "//") {
+			continue
+		}
+		fields := strings.Fields(strings.ReplaceAll(strings.TrimPrefix(trimmed, "//"), ",", " "))
+		if len(fields) == 0 {
+			continue
 		}
-	}
 
-	return issues, nil
+		target := -1
+		switch fields[0] {
+		case "dml-lint-disable-next-line":
+			target = i + 2
+		case "dml-lint-disable-file":
+			target = 0
+		}
+		if target < 0 {
+			continue
+		}
+
+		codes, ok := s[target]
+		if !ok {
+			codes = make(map[string]bool)
+			s[target] = codes
+		}
+		if len(fields) == 1 {
+			// An empty set means every rule; keep it empty even if codes
+			// were listed by an earlier directive.
+			s[target] = map[string]bool{}
+			continue
+		}
+		if len(codes) == 0 && ok {
+			continue
+		}
+		for _, code := range fields[1:] {
+			codes[code] = true
+		}
+	}
+	return s
 }
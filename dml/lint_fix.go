@@ -0,0 +1,130 @@
+package dml
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// TextEdit replaces the bytes [Start, End) of the linted source with NewText.
+// An insertion has Start == End; a deletion has an empty NewText.
+type TextEdit struct {
+	Start   int    `json:"start"`
+	End     int    `json:"end"`
+	NewText string `json:"text"`
+}
+
+// SuggestedFix is a mechanical change that resolves a lint issue. Its edits
+// touch only the bytes needed, so the rest of the file is left as written.
+type SuggestedFix struct {
+	Description string     `json:"description"`
+	Edits       []TextEdit `json:"edits"`
+}
+
+// maxFixPasses bounds how often FixSource re-lints; fixes that only become
+// visible after an earlier fix (such as a second missing semicolon) need
+// another pass.
+const maxFixPasses = 10
+
+// ApplyFixes applies the suggested fixes of issues to src and returns the
+// result with the number of fixes applied. A fix whose edits overlap an
+// earlier one is skipped whole; linting the result again will offer it anew.
+func ApplyFixes(src []byte, issues []LintIssue) ([]byte, int) {
+	var edits []textEdit
+	var taken []TextEdit
+	applied := 0
+
+	for _, it := range issues {
+		if it.Fix == nil || len(it.Fix.Edits) == 0 || !fixFits(it.Fix, taken, len(src)) {
+			continue
+		}
+		for _, e := range it.Fix.Edits {
+			edits = append(edits, textEdit{start: e.Start, end: e.End, text: e.NewText})
+			taken = append(taken, e)
+		}
+		applied++
+	}
+	if applied == 0 {
+		return src, 0
+	}
+	return []byte(applyEdits(string(src), edits)), applied
+}
+
+// fixFits reports whether every edit of fix is in range and clear of taken.
+func fixFits(fix *SuggestedFix, taken []TextEdit, size int) bool {
+	for i, e := range fix.Edits {
+		if e.Start < 0 || e.End < e.Start || e.End > size {
+			return false
+		}
+		for _, other := range taken {
+			if editsOverlap(e, other) {
+				return false
+			}
+		}
+		for _, other := range fix.Edits[:i] {
+			if editsOverlap(e, other) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// editsOverlap also treats two insertions at one offset as overlapping, since
+// their order would be ambiguous.
+func editsOverlap(a, b TextEdit) bool {
+	return a.Start < b.End && b.Start < a.End || a.Start == b.Start
+}
+
+// FixSource lints src and applies every suggested fix, repeating until no
+// fix is left. The result must parse; otherwise src is returned unchanged
+// with the parse error, so a fix can never leave a broken file behind.
+func FixSource(src []byte, opts LintOptions) ([]byte, int, error) {
+	fixed, total := src, 0
+	for pass := 0; pass < maxFixPasses; pass++ {
+		issues, err := LintSourceWithOptions(fixed, opts)
+		if err != nil {
+			return src, 0, err
+		}
+		var n int
+		if fixed, n = ApplyFixes(fixed, issues); n == 0 {
+			break
+		}
+		total += n
+	}
+	if total == 0 {
+		return src, 0, nil
+	}
+
+	text := strings.ReplaceAll(string(fixed), "\r\n", "\n")
+	if err := New().Parse(text); err != nil {
+		return src, 0, fmt.Errorf("fixed source does not parse: %w", err)
+	}
+	return fixed, total, nil
+}
+
+// deleteFix removes s from the source.
+func deleteFix(description string, s span) *SuggestedFix {
+	return &SuggestedFix{Description: description, Edits: []TextEdit{{Start: s.start, End: s.end}}}
+}
+
+// missingSemicolonFix appends ';' to the line Parse reported, when the parse
+// error is a missing semicolon.
+func (ctx *LintContext) missingSemicolonFix() *SuggestedFix {
+	var dmlErr *DMLError
+	if !errors.As(ctx.parseErr, &dmlErr) || dmlErr.Code != "MISSING_SEMICOLON" {
+		return nil
+	}
+	if dmlErr.Line < 1 || dmlErr.Line > len(ctx.file.lineStarts) {
+		return nil
+	}
+	start, end := ctx.file.lineBounds(dmlErr.Line - 1)
+	line := trimSpan(ctx.file.src, span{start, end})
+	if !line.valid() {
+		return nil
+	}
+	return &SuggestedFix{
+		Description: ctx.Message("FIX_ADD_SEMICOLON"),
+		Edits:       []TextEdit{{Start: line.end, End: line.end, NewText: ";"}},
+	}
+}
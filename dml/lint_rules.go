@@ -0,0 +1,396 @@
+package dml
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+func init() {
+	RegisterLintRule(NewLintRule("PARSE_ERROR", LintLevelError,
+		"The file does not parse.", checkParseError))
+	RegisterLintRule(NewLintRule("MAP_UNCLOSED", LintLevelError,
+		"A map literal is never closed with '}'.", checkMapUnclosed))
+	RegisterLintRule(NewLintRule("TYPED_MAP_ENTRY", LintLevelError,
+		"Entries inside a map literal must not carry a type keyword.", checkTypedMapEntry))
+	RegisterLintRule(NewLintRule("EMPTY_MAP", LintLevelWarning,
+		"A map literal has no entries.", checkEmptyMap))
+	RegisterLintRule(NewLintRule("MAP_TRAILING_COMMA", LintLevelError,
+		"The last entry of a map literal is followed by a comma.", checkMapTrailingComma))
+	RegisterLintRule(NewLintRule("MIXED_MAP_STYLE", LintLevelWarning,
+		"The file mixes map declarations with plain root variables.", checkMixedMapStyle))
+	RegisterLintRule(NewLintRule("MIXED_MAP_DECLARATION", LintLevelWarning,
+		"The file declares maps both as literals and as dotted keys.", checkMixedMapDeclaration))
+	RegisterLintRule(NewLintRule("UNUSED_DEFAULT", LintLevelWarning,
+		"A default declaration is never assigned.", checkUnusedDefault))
+	RegisterLintRule(NewLintRule("DUPLICATE_KEY", LintLevelError,
+		"A key is declared twice, or repeated inside one map literal; the last one wins.", checkDuplicateKey))
+	RegisterLintRule(NewLintRule("SHADOWED_KEY", LintLevelError,
+		"A declaration overwrites a value declared earlier through a dotted key or a map literal.", checkShadowedKey))
+	RegisterLintRule(NewLintRule("KEY_NAMING", LintLevelWarning,
+		"Keys follow the convention set by `naming` in .dmllint (snake_case or camelCase).", checkKeyNaming))
+	RegisterLintRule(NewLintRule("KEY_CASE_CONFLICT", LintLevelWarning,
+		"Two keys differ only by letter case.", checkKeyCaseConflict))
+}
+
+// lintTypedEntryRe matches map entries written like declarations, such as
+// `string port = 8080` or `string "port": 8080`, capturing the key.
+var lintTypedEntryRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*\s+"?([A-Za-z_][A-Za-z0-9_.]*)"?\s*[=:]`)
+
+// issueAt builds an issue covering a span of the source; the end position
+// is exclusive.
+func (ctx *LintContext) issueAt(s span, message string) LintIssue {
+	line, col := ctx.file.position(s.start)
+	endLine, endCol := ctx.file.position(s.end)
+	return LintIssue{Line: line, Column: col, EndLine: endLine, EndColumn: endCol, Message: message}
+}
+
+// literal is a `{...}` or `[...]` value found in a declaration, with the
+// dotted key it is stored under.
+type literal struct {
+	decl *cstNode
+	key  string
+	span span
+}
+
+// literals returns every map and list literal in the file, nested ones
+// included, in source order.
+func (ctx *LintContext) literals() []literal {
+	f := ctx.file
+	var out []literal
+	var walk func(decl *cstNode, key string, s span)
+	walk = func(decl *cstNode, key string, s span) {
+		if !s.valid() || (f.src[s.start] != '{' && f.src[s.start] != '[') {
+			return
+		}
+		out = append(out, literal{decl: decl, key: key, span: s})
+		for i, e := range f.entries(s) {
+			child := fmt.Sprintf("%s[%d]", key, i)
+			if e.key.valid() {
+				child = key + "." + e.name
+			}
+			walk(decl, child, e.value)
+		}
+	}
+	for _, n := range f.nodes {
+		if n.kind == cstDecl && n.name.valid() {
+			walk(n, n.declName(f), n.value)
+		}
+	}
+	return out
+}
+
+func checkParseError(ctx *LintContext) []LintIssue {
+	if ctx.parseErr == nil {
+		return nil
+	}
+	var dmlErr *DMLError
+	if errors.As(ctx.parseErr, &dmlErr) {
+		return []LintIssue{{Line: dmlErr.Line, Column: dmlErr.Column, Message: dmlErr.Message,
+			Fix: ctx.missingSemicolonFix()}}
+	}
+	return []LintIssue{{Line: 1, Column: 1, Message: ctx.parseErr.Error()}}
+}
+
+func checkMapUnclosed(ctx *LintContext) []LintIssue {
+	f := ctx.file
+	var issues []LintIssue
+	for _, n := range f.nodes {
+		if n.kind != cstDecl || !n.unclosed || !n.value.valid() || f.src[n.value.start] != '{' {
+			continue
+		}
+		issues = append(issues, ctx.issueAt(n.value,
+			ctx.Message("MAP_UNCLOSED", n.declName(f))))
+	}
+	return issues
+}
+
+func checkTypedMapEntry(ctx *LintContext) []LintIssue {
+	f := ctx.file
+	var issues []LintIssue
+	for _, lit := range ctx.literals() {
+		if f.src[lit.span.start] != '{' {
+			continue
+		}
+		for _, e := range f.entries(lit.span) {
+			m := lintTypedEntryRe.FindStringSubmatchIndex(f.text(span{e.start, e.end}))
+			if m == nil {
+				continue
+			}
+			key := f.src[e.start+m[2] : e.start+m[3]]
+			it := ctx.issueAt(span{e.start, e.end}, ctx.Message("TYPED_MAP_ENTRY"))
+			it.Fix = &SuggestedFix{
+				Description: ctx.Message("FIX_UNTYPED_ENTRY", key),
+				Edits:       []TextEdit{{Start: e.start, End: e.start + m[1], NewText: strconv.Quote(key) + ":"}},
+			}
+			issues = append(issues, it)
+		}
+	}
+	return issues
+}
+
+func checkEmptyMap(ctx *LintContext) []LintIssue {
+	f := ctx.file
+	var issues []LintIssue
+	for _, lit := range ctx.literals() {
+		if f.src[lit.span.start] == '{' && f.src[lit.span.end-1] == '}' && len(f.entries(lit.span)) == 0 {
+			issues = append(issues, ctx.issueAt(lit.span, ctx.Message("EMPTY_MAP", lit.key)))
+		}
+	}
+	return issues
+}
+
+func checkMapTrailingComma(ctx *LintContext) []LintIssue {
+	f := ctx.file
+	var issues []LintIssue
+	for _, lit := range ctx.literals() {
+		if f.src[lit.span.start] != '{' {
+			continue
+		}
+		entries := f.entries(lit.span)
+		if len(entries) > 0 && entries[len(entries)-1].comma >= 0 {
+			comma := entries[len(entries)-1].comma
+			it := ctx.issueAt(span{comma, comma + 1}, ctx.Message("MAP_TRAILING_COMMA", lit.key))
+			it.Fix = deleteFix(ctx.Message("FIX_REMOVE_COMMA"), span{comma, comma + 1})
+			issues = append(issues, it)
+		}
+	}
+	return issues
+}
+
+// checkMixedMapStyle reports a file that declares both maps and root
+// variables, top-level keys holding a scalar or list, at the first
+// declaration that makes the mix.
+func checkMixedMapStyle(ctx *LintContext) []LintIssue {
+	f := ctx.file
+	var first string
+	for _, n := range f.nodes {
+		if n.kind != cstDecl || !n.name.valid() || !n.value.valid() || n.declType(f) == "default" {
+			continue
+		}
+		var style string
+		switch {
+		case f.src[n.value.start] == '{':
+			style = "map"
+		case !strings.Contains(n.declName(f), "."):
+			style = "root"
+		default:
+			continue
+		}
+		if first == "" {
+			first = style
+			continue
+		}
+		if style != first {
+			return []LintIssue{ctx.issueAt(n.name, ctx.Message("MIXED_MAP_STYLE"))}
+		}
+	}
+	return nil
+}
+
+// checkMixedMapDeclaration reports the first declaration written in a
+// different map style from the ones before it: a map literal after dotted
+// keys, or a dotted key after a map literal.
+func checkMixedMapDeclaration(ctx *LintContext) []LintIssue {
+	f := ctx.file
+	var first string
+	for _, n := range f.nodes {
+		if n.kind != cstDecl || !n.name.valid() || !n.value.valid() {
+			continue
+		}
+		var style string
+		switch {
+		case f.src[n.value.start] == '{':
+			style = "literal"
+		case strings.Contains(n.declName(f), "."):
+			style = "dotted"
+		default:
+			continue
+		}
+		if first == "" {
+			first = style
+			continue
+		}
+		if style != first {
+			return []LintIssue{ctx.issueAt(n.name, ctx.Message("MIXED_MAP_DECLARATION"))}
+		}
+	}
+	return nil
+}
+
+func checkUnusedDefault(ctx *LintContext) []LintIssue {
+	f := ctx.file
+	assigned := make(map[string]int)
+	for _, n := range f.nodes {
+		if n.kind == cstDecl && n.name.valid() && n.declType(f) != "default" {
+			assigned[n.declName(f)]++
+		}
+	}
+
+	var issues []LintIssue
+	for _, n := range f.nodes {
+		if n.kind == cstDecl && n.name.valid() && n.declType(f) == "default" && assigned[n.declName(f)] == 0 {
+			it := ctx.issueAt(n.name, ctx.Message("UNUSED_DEFAULT", n.declName(f)))
+			it.Fix = deleteFix(ctx.Message("FIX_REMOVE_DEFAULT", n.declName(f)), span{n.start, n.end})
+			issues = append(issues, it)
+		}
+	}
+	return issues
+}
+
+// keyOccurrence is a key written in the file: a declaration name or a map
+// literal entry, with its full dotted path.
+type keyOccurrence struct {
+	path  string
+	name  string
+	at    span
+	decl  *cstNode
+	isMap bool
+}
+
+// keyOccurrences lists declaration names and map literal keys in source
+// order. Entries of lists are skipped; their keys are not addressable by a
+// dotted path.
+func (ctx *LintContext) keyOccurrences() []keyOccurrence {
+	f := ctx.file
+	var out []keyOccurrence
+	var walk func(decl *cstNode, prefix string, s span)
+	walk = func(decl *cstNode, prefix string, s span) {
+		if !s.valid() || f.src[s.start] != '{' {
+			return
+		}
+		for _, e := range f.entries(s) {
+			if !e.key.valid() {
+				continue
+			}
+			path := prefix + "." + e.name
+			isMap := e.value.valid() && f.src[e.value.start] == '{'
+			out = append(out, keyOccurrence{path: path, name: e.name, at: e.key, decl: decl, isMap: isMap})
+			walk(decl, path, e.value)
+		}
+	}
+	for _, n := range f.nodes {
+		if n.kind != cstDecl || !n.name.valid() {
+			continue
+		}
+		name := n.declName(f)
+		isMap := n.value.valid() && f.src[n.value.start] == '{'
+		out = append(out, keyOccurrence{path: name, name: name, at: n.name, decl: n, isMap: isMap})
+		walk(n, name, n.value)
+	}
+	return out
+}
+
+func checkDuplicateKey(ctx *LintContext) []LintIssue {
+	var issues []LintIssue
+	declared := make(map[string]*cstNode)
+	inLiteral := make(map[string]keyOccurrence)
+	for _, k := range ctx.keyOccurrences() {
+		if k.at == k.decl.name {
+			if earlier, ok := declared[k.path]; ok && earlier.declType(ctx.file) != "default" && k.decl.declType(ctx.file) != "default" {
+				issues = append(issues, ctx.issueAt(k.at, ctx.Message("DUPLICATE_KEY", k.path, earlier.line)))
+			}
+			declared[k.path] = k.decl
+			continue
+		}
+		if earlier, ok := inLiteral[k.path]; ok && earlier.decl == k.decl {
+			line, _ := ctx.file.position(earlier.at.start)
+			issues = append(issues, ctx.issueAt(k.at, ctx.Message("DUPLICATE_KEY", k.path, line)))
+		}
+		inLiteral[k.path] = k
+	}
+	return issues
+}
+
+// checkShadowedKey flags a declaration that lands on a value set by an
+// earlier declaration: a key inside an earlier map literal, a key below an
+// earlier scalar, or a key that replaces earlier dotted keys beneath it.
+// Repeating a declaration outright is left to DUPLICATE_KEY.
+func checkShadowedKey(ctx *LintContext) []LintIssue {
+	f := ctx.file
+	var issues []LintIssue
+	var earlier []keyOccurrence
+	for _, k := range ctx.keyOccurrences() {
+		if k.at != k.decl.name || k.decl.declType(f) == "default" {
+			earlier = append(earlier, k)
+			continue
+		}
+		for i := len(earlier) - 1; i >= 0; i-- {
+			e := earlier[i]
+			if e.decl == k.decl || e.decl.declType(f) == "default" {
+				continue
+			}
+			clobbers := false
+			switch {
+			case e.path == k.path:
+				clobbers = e.at != e.decl.name
+			case strings.HasPrefix(k.path, e.path+"."):
+				clobbers = !e.isMap
+			case strings.HasPrefix(e.path, k.path+"."):
+				clobbers = true
+			}
+			if clobbers {
+				issues = append(issues, ctx.issueAt(k.at, ctx.Message("SHADOWED_KEY", k.path, e.path, e.decl.line)))
+				break
+			}
+		}
+		earlier = append(earlier, k)
+	}
+	return issues
+}
+
+var keyNamingStyles = map[string]*regexp.Regexp{
+	"snake_case": regexp.MustCompile(`^[a-z][a-z0-9]*(_[a-z0-9]+)*$`),
+	"camelCase":  regexp.MustCompile(`^[a-z][a-zA-Z0-9]*$`),
+}
+
+// checkKeyNaming checks every key segment against the style named by the
+// `naming` setting. Without the setting the rule reports nothing.
+func checkKeyNaming(ctx *LintContext) []LintIssue {
+	style := ctx.Options.GetString("naming")
+	re, ok := keyNamingStyles[style]
+	if !ok {
+		return nil
+	}
+	var issues []LintIssue
+	for _, k := range ctx.keyOccurrences() {
+		for _, segment := range strings.Split(k.name, ".") {
+			if !re.MatchString(segment) {
+				issues = append(issues, ctx.issueAt(k.at, ctx.Message("KEY_NAMING", segment, style)))
+				break
+			}
+		}
+	}
+	return issues
+}
+
+// checkKeyCaseConflict compares every prefix of every key path, so
+// `int a.Port.x` and `int a.port.y` conflict even though their full paths
+// differ.
+func checkKeyCaseConflict(ctx *LintContext) []LintIssue {
+	type seen struct {
+		path string
+		line int
+	}
+	var issues []LintIssue
+	first := make(map[string]seen)
+	for _, k := range ctx.keyOccurrences() {
+		line, _ := ctx.file.position(k.at.start)
+		parts := strings.Split(k.path, ".")
+		for i := range parts {
+			prefix := strings.Join(parts[:i+1], ".")
+			prev, ok := first[strings.ToLower(prefix)]
+			if !ok {
+				first[strings.ToLower(prefix)] = seen{prefix, line}
+				continue
+			}
+			if prev.path != prefix {
+				issues = append(issues, ctx.issueAt(k.at, ctx.Message("KEY_CASE_CONFLICT", prefix, prev.path, prev.line)))
+				break
+			}
+		}
+	}
+	return issues
+}
@@ -0,0 +1,227 @@
+package dml
+
+import (
+	"fmt"
+	"reflect"
+)
+
+type ListStrategy int
+
+const (
+	// ListReplace treats differing lists like any other conflicting value.
+	ListReplace ListStrategy = iota
+	// ListAppend concatenates lists in merge order.
+	ListAppend
+	// ListUniqueAppend concatenates lists, skipping items already present.
+	ListUniqueAppend
+)
+
+type ConflictStrategy int
+
+const (
+	ConflictLastWins ConflictStrategy = iota
+	ConflictFirstWins
+	ConflictError
+)
+
+type TypeMismatchStrategy int
+
+const (
+	// TypeMismatchError fails the merge when a key has different DML types
+	// in two configs, e.g. a map in one and a string in another.
+	TypeMismatchError TypeMismatchStrategy = iota
+	// TypeMismatchOverride resolves type mismatches through the Conflict
+	// strategy, like any other conflicting value.
+	TypeMismatchOverride
+)
+
+// MergeOptions controls Merge. The zero value replaces lists, lets later
+// configs win and rejects type mismatches.
+type MergeOptions struct {
+	Lists        ListStrategy
+	Conflict     ConflictStrategy
+	TypeMismatch TypeMismatchStrategy
+}
+
+// MergeConflict records a key that had different values in two configs and
+// how it was resolved. Source is the index of the conflicting config in srcs.
+type MergeConflict struct {
+	Key          string
+	Source       int
+	Existing     any
+	Incoming     any
+	TypeMismatch bool
+	Kept         any
+}
+
+func (c MergeConflict) String() string {
+	if c.TypeMismatch {
+		return fmt.Sprintf("%s: %s %v vs %s %v", c.Key, dmlTypeOf(c.Existing), c.Existing, dmlTypeOf(c.Incoming), c.Incoming)
+	}
+	return fmt.Sprintf("%s: %v vs %v", c.Key, c.Existing, c.Incoming)
+}
+
+// Merge deep-merges srcs into dst in order. Maps are merged key by key;
+// lists and scalars follow opts. It returns every conflict it resolved.
+// On error dst is left unchanged.
+func Merge(dst *Config, opts MergeOptions, srcs ...*Config) ([]MergeConflict, error) {
+	m := &merger{opts: opts}
+	data := deepCopy(dst.data).(map[string]any)
+	types := make(map[string]string, len(dst.types))
+	for k, v := range dst.types {
+		types[k] = v
+	}
+
+	for i, src := range srcs {
+		m.source = i
+		m.fromSrc = nil
+		if err := m.mergeMaps("", data, src.data); err != nil {
+			return m.conflicts, err
+		}
+		for k, v := range src.types {
+			if _, ok := types[k]; !ok || m.fromSrc[k] {
+				types[k] = v
+			}
+		}
+	}
+
+	dst.data = data
+	dst.types = types
+	dst.syncTypes()
+	return m.conflicts, nil
+}
+
+type merger struct {
+	opts      MergeOptions
+	source    int
+	conflicts []MergeConflict
+	fromSrc   map[string]bool
+}
+
+func (m *merger) mergeMaps(prefix string, dst, src map[string]any) error {
+	for _, k := range sortedMapKeys(src) {
+		key := joinKey(prefix, k)
+		incoming := src[k]
+		existing, ok := dst[k]
+		if !ok {
+			dst[k] = deepCopy(incoming)
+			m.took(key)
+			continue
+		}
+
+		dstMap, dstIsMap := existing.(map[string]any)
+		srcMap, srcIsMap := incoming.(map[string]any)
+		if dstIsMap && srcIsMap {
+			if err := m.mergeMaps(key, dstMap, srcMap); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if dmlTypeOf(existing) != dmlTypeOf(incoming) {
+			if m.opts.TypeMismatch == TypeMismatchError {
+				return fmt.Errorf("merge: type mismatch at '%s': %s vs %s", key, dmlTypeOf(existing), dmlTypeOf(incoming))
+			}
+			if err := m.resolve(dst, k, key, existing, incoming, true); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if list, ok := existing.([]any); ok && m.opts.Lists != ListReplace {
+			dst[k] = m.mergeLists(list, incoming.([]any))
+			continue
+		}
+
+		if reflect.DeepEqual(existing, incoming) {
+			continue
+		}
+		if err := m.resolve(dst, k, key, existing, incoming, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *merger) resolve(dst map[string]any, k, key string, existing, incoming any, typeMismatch bool) error {
+	c := MergeConflict{Key: key, Source: m.source, Existing: existing, Incoming: incoming, TypeMismatch: typeMismatch}
+	switch m.opts.Conflict {
+	case ConflictError:
+		m.conflicts = append(m.conflicts, c)
+		return fmt.Errorf("merge: conflicting values at '%s'", key)
+	case ConflictFirstWins:
+		c.Kept = existing
+	default:
+		c.Kept = incoming
+		dst[k] = deepCopy(incoming)
+		m.took(key)
+	}
+	m.conflicts = append(m.conflicts, c)
+	return nil
+}
+
+func (m *merger) mergeLists(dst, src []any) []any {
+	out := append([]any{}, dst...)
+	for _, item := range src {
+		if m.opts.Lists == ListUniqueAppend && listContains(out, item) {
+			continue
+		}
+		out = append(out, deepCopy(item))
+	}
+	return out
+}
+
+// took records that key now holds a value from the current source, so its
+// declared type should follow.
+func (m *merger) took(key string) {
+	if m.fromSrc == nil {
+		m.fromSrc = make(map[string]bool)
+	}
+	m.fromSrc[key] = true
+}
+
+func listContains(list []any, item any) bool {
+	for _, v := range list {
+		if reflect.DeepEqual(v, item) {
+			return true
+		}
+	}
+	return false
+}
+
+// Clone returns a deep copy of the config.
+func (c *Config) Clone() *Config {
+	out := New()
+	out.data = deepCopy(c.data).(map[string]any)
+	out.mapStyle = c.mapStyle
+	out.schema = c.schema
+	out.locale = c.locale
+	out.strict = c.strict
+	out.env = c.env
+	out.decls = append([]Declaration(nil), c.decls...)
+	for k, v := range c.defaultKeys {
+		out.defaultKeys[k] = v
+	}
+	for k, v := range c.types {
+		out.types[k] = v
+	}
+	return out
+}
+
+func deepCopy(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = deepCopy(item)
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = deepCopy(item)
+		}
+		return out
+	}
+	return v
+}
@@ -0,0 +1,102 @@
+package dml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMerge_DeepMapsAndLastWins(t *testing.T) {
+	dst := mustParse(t, `map db = {"host": "localhost", "pool": 5};
+int port = 80;`)
+	src := mustParse(t, `map db = {"pool": 10, "ssl": true};
+string name = "api";`)
+
+	conflicts, err := Merge(dst, MergeOptions{}, src)
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+
+	if dst.GetString("db.host") != "localhost" || dst.GetInt("db.pool") != 10 || !dst.GetBool("db.ssl") {
+		t.Errorf("unexpected db after merge: %#v", dst.GetMap("db"))
+	}
+	if dst.GetString("name") != "api" || dst.GetInt("port") != 80 {
+		t.Errorf("expected keys from both configs, got %v", dst.Keys())
+	}
+	if len(conflicts) != 1 || conflicts[0].Key != "db.pool" || conflicts[0].Kept != 10 {
+		t.Errorf("expected one db.pool conflict resolved to 10, got %+v", conflicts)
+	}
+	if typ, _ := dst.DeclaredType("name"); typ != "string" {
+		t.Errorf("expected declared type of name to carry over, got %q", typ)
+	}
+
+	// src must not be aliased into dst.
+	dst.Set("db.ssl", false)
+	if !src.GetBool("db.ssl") {
+		t.Error("merge aliased maps from src into dst")
+	}
+}
+
+func TestMerge_FirstWins(t *testing.T) {
+	dst := mustParse(t, `int port = 80;`)
+	conflicts, err := Merge(dst, MergeOptions{Conflict: ConflictFirstWins},
+		mustParse(t, `int port = 81;`), mustParse(t, `int port = 82;`))
+	if err != nil {
+		t.Fatalf("Merge: %v", err)
+	}
+	if dst.GetInt("port") != 80 {
+		t.Errorf("expected first value to win, got %d", dst.GetInt("port"))
+	}
+	if len(conflicts) != 2 || conflicts[1].Source != 1 {
+		t.Errorf("expected two conflicts, the second from source 1, got %+v", conflicts)
+	}
+}
+
+func TestMerge_Lists(t *testing.T) {
+	tests := []struct {
+		lists ListStrategy
+		want  []any
+	}{
+		{ListReplace, []any{"b", "c"}},
+		{ListAppend, []any{"a", "b", "b", "c"}},
+		{ListUniqueAppend, []any{"a", "b", "c"}},
+	}
+	for _, tt := range tests {
+		dst := mustParse(t, `list tags = ["a", "b"];`)
+		if _, err := Merge(dst, MergeOptions{Lists: tt.lists}, mustParse(t, `list tags = ["b", "c"];`)); err != nil {
+			t.Fatalf("Merge: %v", err)
+		}
+		if got := dst.GetList("tags"); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lists=%d: expected %v, got %v", tt.lists, tt.want, got)
+		}
+	}
+}
+
+func TestMerge_ErrorsLeaveDstUnchanged(t *testing.T) {
+	dst := mustParse(t, `int port = 80;
+map db = {"pool": 5};`)
+
+	_, err := Merge(dst, MergeOptions{Conflict: ConflictError}, mustParse(t, `string extra = "x";
+int port = 81;`))
+	if err == nil {
+		t.Fatal("expected conflict error")
+	}
+	if dst.Has("extra") || dst.GetInt("port") != 80 {
+		t.Errorf("dst was modified by a failed merge: %v", dst.Keys())
+	}
+
+	_, err = Merge(dst, MergeOptions{}, mustParse(t, `string db = "postgres://x";`))
+	if err == nil {
+		t.Fatal("expected type mismatch error")
+	}
+
+	conflicts, err := Merge(dst, MergeOptions{TypeMismatch: TypeMismatchOverride}, mustParse(t, `string db = "postgres://x";`))
+	if err != nil {
+		t.Fatalf("Merge with override: %v", err)
+	}
+	if dst.GetString("db") != "postgres://x" || len(conflicts) != 1 || !conflicts[0].TypeMismatch {
+		t.Errorf("expected db overridden with a type mismatch conflict, got %v %+v", dst.GetString("db"), conflicts)
+	}
+	if typ, _ := dst.DeclaredType("db"); typ != "string" {
+		t.Errorf("expected db type to follow the new value, got %q", typ)
+	}
+}
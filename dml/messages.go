@@ -0,0 +1,207 @@
+package dml
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Messages shown to users are looked up by a stable code in per-locale
+// catalogs. English is the fallback for locales or codes without a
+// translation, so the code itself is what tools should key on.
+
+const DefaultLocale = "en"
+
+var (
+	localeMu      sync.RWMutex
+	currentLocale = DefaultLocale
+	catalogs      = map[string]map[string]string{
+		"en": messagesEN,
+		"pl": messagesPL,
+	}
+)
+
+var messagesEN = map[string]string{
+	"ERROR_HEADER":          "%s at line %d:%d",
+	"ERROR_SYNTAX":          "Syntax Error",
+	"ERROR_VALIDATION":      "Validation Error",
+	"ERROR_TYPE":            "Type Error",
+	"ERROR_UNKNOWN":         "Unknown Error",
+	"MISSING_SEMICOLON":     "Missing semicolon at the end of declaration",
+	"UNCLOSED_DECLARATION":  "Unclosed declaration (missing ';')",
+	"INVALID_DIRECTIVE":     "Invalid directive: @%s",
+	"UNKNOWN_DIRECTIVE":     "Unknown directive: @%s",
+	"INVALID_MAP_STYLE":     "Invalid mapStyle value: %s (expected: json, flat, or auto)",
+	"MISSING_EQUALS":        "Missing '=' operator in variable declaration",
+	"INVALID_DECLARATION":   "Invalid variable declaration format. Expected: type name = value",
+	"INVALID_IDENTIFIER":    "Invalid identifier. Must start with letter or underscore, and contain only letters, digits, underscores, or dots",
+	"UNKNOWN_TYPE":          "Unknown type: %s",
+	"INVALID_STRING":        "String must be enclosed in double quotes",
+	"INVALID_INT":           "Invalid integer value: %s",
+	"INVALID_FLOAT":         "Invalid float value: %s",
+	"INVALID_BOOL":          "Boolean must be 'true' or 'false'",
+	"INVALID_DURATION":      "Invalid duration value: %s",
+	"INVALID_LIST":          "List must be enclosed in square brackets []",
+	"INVALID_MAP":           "Map must be enclosed in curly braces {}",
+	"INVALID_MAP_ENTRY":     "Map entries must be in 'key: value' format",
+	"MAP_UNCLOSED":          "Map %q is never closed",
+	"TYPED_MAP_ENTRY":       "Map entries must not have a type (e.g. 'string port = ...'); write \"port\": value",
+	"EMPTY_MAP":             "Map %q is empty",
+	"MAP_TRAILING_COMMA":    "Trailing comma after the last entry of map %q",
+	"MIXED_MAP_STYLE":       "The file uses both maps and root variables; pick one style",
+	"MIXED_MAP_DECLARATION": "Maps are declared both as literals and as dotted keys; pick one style",
+	"UNUSED_DEFAULT":        "Default %q is never used",
+	"DUPLICATE_KEY":         "Key %q is already declared on line %d",
+	"SHADOWED_KEY":          "Key %q overwrites %q declared on line %d",
+	"KEY_NAMING":            "Key %q is not %s",
+	"KEY_CASE_CONFLICT":     "Key %q differs from %q on line %d only by case",
+	"SECRET_KEY_LITERAL":    "Key %q holds a literal secret; use an environment reference such as \"${%s}\"",
+	"SECRET_TOKEN_PREFIX":   "Value of %q looks like a %s",
+	"SECRET_HIGH_ENTROPY":   "Value of %q looks like a hard-coded secret (high-entropy string)",
+	"ENV_REQUIRED":          "Environment variable %s required by %q: %s",
+	"ENV_NOT_SET":           "not set",
+	"ENV_INVALID_VALUE":     "Value %q of %q is not a valid %s",
+	"FIX_ADD_SEMICOLON":     "Add ';'",
+	"FIX_REMOVE_COMMA":      "Remove the trailing comma",
+	"FIX_UNTYPED_ENTRY":     "Write the entry as %q: value",
+	"FIX_REMOVE_DEFAULT":    "Remove the unused default %q",
+}
+
+var messagesPL = map[string]string{
+	"ERROR_HEADER":          "%s w linii %d:%d",
+	"ERROR_SYNTAX":          "Błąd składni",
+	"ERROR_VALIDATION":      "Błąd walidacji",
+	"ERROR_TYPE":            "Błąd typu",
+	"ERROR_UNKNOWN":         "Nieznany błąd",
+	"MISSING_SEMICOLON":     "Brak średnika na końcu deklaracji",
+	"UNCLOSED_DECLARATION":  "Niezamknięta deklaracja (brak ';')",
+	"INVALID_DIRECTIVE":     "Nieprawidłowa dyrektywa: @%s",
+	"UNKNOWN_DIRECTIVE":     "Nieznana dyrektywa: @%s",
+	"INVALID_MAP_STYLE":     "Nieprawidłowa wartość mapStyle: %s (oczekiwano: json, flat lub auto)",
+	"MISSING_EQUALS":        "Brak operatora '=' w deklaracji zmiennej",
+	"INVALID_DECLARATION":   "Nieprawidłowy format deklaracji. Oczekiwano: typ nazwa = wartość",
+	"INVALID_IDENTIFIER":    "Nieprawidłowy identyfikator. Musi zaczynać się literą lub podkreślnikiem i zawierać tylko litery, cyfry, podkreślniki lub kropki",
+	"UNKNOWN_TYPE":          "Nieznany typ: %s",
+	"INVALID_STRING":        "Tekst musi być ujęty w podwójny cudzysłów",
+	"INVALID_INT":           "Nieprawidłowa liczba całkowita: %s",
+	"INVALID_FLOAT":         "Nieprawidłowa liczba zmiennoprzecinkowa: %s",
+	"INVALID_BOOL":          "Wartość logiczna musi być 'true' lub 'false'",
+	"INVALID_DURATION":      "Nieprawidłowy czas trwania: %s",
+	"INVALID_LIST":          "Lista musi być ujęta w nawiasy kwadratowe []",
+	"INVALID_MAP":           "Mapa musi być ujęta w nawiasy klamrowe {}",
+	"INVALID_MAP_ENTRY":     "Wpisy mapy muszą mieć format 'klucz: wartość'",
+	"MAP_UNCLOSED":          "Mapa %q nie została zamknięta",
+	"TYPED_MAP_ENTRY":       "Wpisy mapy nie mogą mieć typu (np. 'string port = ...'); zapisz \"port\": wartość",
+	"EMPTY_MAP":             "Mapa %q jest pusta",
+	"MAP_TRAILING_COMMA":    "Końcowy przecinek po ostatnim wpisie mapy %q",
+	"MIXED_MAP_STYLE":       "Plik używa zarówno map, jak i zmiennych root; wybierz jeden styl",
+	"MIXED_MAP_DECLARATION": "Mapy są zadeklarowane zarówno jako literały, jak i jako klucze z kropkami; wybierz jeden styl",
+	"UNUSED_DEFAULT":        "Wartość domyślna %q nigdy nie jest używana",
+	"DUPLICATE_KEY":         "Klucz %q jest już zadeklarowany w linii %d",
+	"SHADOWED_KEY":          "Klucz %q nadpisuje %q zadeklarowany w linii %d",
+	"KEY_NAMING":            "Klucz %q nie jest zapisany w stylu %s",
+	"KEY_CASE_CONFLICT":     "Klucz %q różni się od %q z linii %d tylko wielkością liter",
+	"SECRET_KEY_LITERAL":    "Klucz %q zawiera jawny sekret; użyj odwołania do zmiennej środowiskowej, np. \"${%s}\"",
+	"SECRET_TOKEN_PREFIX":   "Wartość %q wygląda jak %s",
+	"SECRET_HIGH_ENTROPY":   "Wartość %q wygląda jak wpisany na stałe sekret (ciąg o wysokiej entropii)",
+	"ENV_REQUIRED":          "Zmienna środowiskowa %s wymagana przez %q: %s",
+	"ENV_NOT_SET":           "nie jest ustawiona",
+	"ENV_INVALID_VALUE":     "Wartość %q klucza %q nie jest poprawną wartością typu %s",
+	"FIX_ADD_SEMICOLON":     "Dodaj ';'",
+	"FIX_REMOVE_COMMA":      "Usuń końcowy przecinek",
+	"FIX_UNTYPED_ENTRY":     "Zapisz wpis jako %q: wartość",
+	"FIX_REMOVE_DEFAULT":    "Usuń nieużywaną wartość domyślną %q",
+}
+
+// SetLocale selects the language of parse errors and lint messages. It
+// accepts forms like "pl", "pl-PL" or "pl_PL.UTF-8"; locales without a
+// catalog fall back to English.
+func SetLocale(locale string) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	currentLocale = normalizeLocale(locale)
+}
+
+func Locale() string {
+	localeMu.RLock()
+	defer localeMu.RUnlock()
+	return currentLocale
+}
+
+// RegisterMessages adds or overrides translations for locale, for example
+// messages of custom lint rules or a new language.
+func RegisterMessages(locale string, messages map[string]string) {
+	localeMu.Lock()
+	defer localeMu.Unlock()
+	locale = normalizeLocale(locale)
+	catalog, ok := catalogs[locale]
+	if !ok {
+		catalog = make(map[string]string)
+		catalogs[locale] = catalog
+	}
+	for code, msg := range messages {
+		catalog[code] = msg
+	}
+}
+
+// LocaleFromEnv picks a locale from the POSIX variables LC_ALL, LC_MESSAGES
+// and LANG, in that order of precedence.
+func LocaleFromEnv(getenv func(string) string) string {
+	for _, name := range []string{"LC_ALL", "LC_MESSAGES", "LANG"} {
+		if v := getenv(name); v != "" {
+			return normalizeLocale(v)
+		}
+	}
+	return DefaultLocale
+}
+
+func normalizeLocale(locale string) string {
+	locale = strings.ToLower(strings.TrimSpace(locale))
+	if i := strings.IndexAny(locale, "_-.@"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" || locale == "c" || locale == "posix" {
+		return DefaultLocale
+	}
+	return locale
+}
+
+// localize formats the message for code in locale, falling back to English
+// and finally to the code itself.
+func localize(locale, code string, args ...any) string {
+	localeMu.RLock()
+	format, ok := catalogs[normalizeLocale(locale)][code]
+	if !ok {
+		format, ok = catalogs[DefaultLocale][code]
+	}
+	localeMu.RUnlock()
+	if !ok {
+		return code
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// messageLocale returns the language for messages produced by this config.
+func (c *Config) messageLocale() string {
+	if c.locale != "" {
+		return c.locale
+	}
+	return Locale()
+}
+
+// newError builds a DMLError whose message is looked up by code.
+func (c *Config) newError(typ ErrorType, code string, line, column int, context string, args ...any) *DMLError {
+	locale := c.messageLocale()
+	return &DMLError{
+		Type:    typ,
+		Code:    code,
+		Message: localize(locale, code, args...),
+		Line:    line,
+		Column:  column,
+		Context: context,
+		locale:  locale,
+	}
+}
@@ -0,0 +1,132 @@
+package dml
+
+import (
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestParseError_CodeAndLocale(t *testing.T) {
+	defer SetLocale(DefaultLocale)
+
+	src := "int a = 1\n"
+	err := New().Parse(src)
+	var dmlErr *DMLError
+	if !errors.As(err, &dmlErr) {
+		t.Fatalf("expected DMLError, got %v", err)
+	}
+	if dmlErr.Code != "MISSING_SEMICOLON" {
+		t.Errorf("Code = %q, want MISSING_SEMICOLON", dmlErr.Code)
+	}
+	if !strings.Contains(dmlErr.Error(), "Syntax Error at line 1:") {
+		t.Errorf("unexpected English header:\n%s", dmlErr.Error())
+	}
+
+	SetLocale("pl_PL.UTF-8")
+	err = New().Parse(src)
+	if !errors.As(err, &dmlErr) {
+		t.Fatalf("expected DMLError, got %v", err)
+	}
+	if dmlErr.Code != "MISSING_SEMICOLON" {
+		t.Errorf("Code must not depend on the locale, got %q", dmlErr.Code)
+	}
+	if dmlErr.Message != "Brak średnika na końcu deklaracji" {
+		t.Errorf("Message = %q", dmlErr.Message)
+	}
+	if !strings.Contains(dmlErr.Error(), "Błąd składni w linii 1:") {
+		t.Errorf("unexpected Polish header:\n%s", dmlErr.Error())
+	}
+	if dmlErr.Type.String() != "Syntax Error" {
+		t.Errorf("ErrorType.String() must stay English, got %q", dmlErr.Type.String())
+	}
+}
+
+func TestLint_Locale(t *testing.T) {
+	src := []byte("map empty = {};\n")
+
+	issues, _ := LintSource(src)
+	if len(issues) != 1 || issues[0].Message != `Map "empty" is empty` {
+		t.Fatalf("unexpected English issues: %+v", issues)
+	}
+
+	issues, _ = LintSourceWithOptions(src, LintOptions{Locale: "pl"})
+	if len(issues) != 1 || issues[0].Code != "EMPTY_MAP" || issues[0].Message != `Mapa "empty" jest pusta` {
+		t.Fatalf("unexpected Polish issues: %+v", issues)
+	}
+
+	issues, _ = LintSourceWithOptions([]byte("int a = 1\n"), LintOptions{Locale: "pl"})
+	if len(issues) != 1 || issues[0].Code != "PARSE_ERROR" || !strings.Contains(issues[0].Message, "średnika") {
+		t.Fatalf("parse errors should be localized too: %+v", issues)
+	}
+}
+
+func TestRegisterMessages_Fallback(t *testing.T) {
+	// RegisterMessages changes the global registry, so the "de" catalog is
+	// put back the way it was for the tests that follow.
+	localeMu.Lock()
+	prev, had := catalogs["de"]
+	saved := make(map[string]string, len(prev))
+	for code, msg := range prev {
+		saved[code] = msg
+	}
+	localeMu.Unlock()
+	t.Cleanup(func() {
+		localeMu.Lock()
+		defer localeMu.Unlock()
+		if had {
+			catalogs["de"] = saved
+		} else {
+			delete(catalogs, "de")
+		}
+	})
+	RegisterMessages("de", map[string]string{"EMPTY_MAP": "Map %q ist leer"})
+
+	if got := localize("de-DE", "EMPTY_MAP", "x"); got != `Map "x" ist leer` {
+		t.Errorf("registered message: got %q", got)
+	}
+	if got := localize("de", "UNUSED_DEFAULT", "x"); got != `Default "x" is never used` {
+		t.Errorf("missing translation should fall back to English, got %q", got)
+	}
+	if got := localize("fr", "NO_SUCH_CODE"); got != "NO_SUCH_CODE" {
+		t.Errorf("unknown code should return the code, got %q", got)
+	}
+}
+
+func TestLocaleFromEnv(t *testing.T) {
+	env := map[string]string{"LANG": "pl_PL.UTF-8", "LC_MESSAGES": "C"}
+	if got := LocaleFromEnv(func(k string) string { return env[k] }); got != DefaultLocale {
+		t.Errorf("LC_MESSAGES=C should win over LANG, got %q", got)
+	}
+	delete(env, "LC_MESSAGES")
+	if got := LocaleFromEnv(func(k string) string { return env[k] }); got != "pl" {
+		t.Errorf("got %q, want pl", got)
+	}
+}
+
+var formatVerbRe = regexp.MustCompile(`%[-+# 0]*[0-9]*(?:\.[0-9]+)?[a-zA-Z]`)
+
+func TestCatalogs_MatchEnglish(t *testing.T) {
+	verbs := func(format string) []string {
+		return formatVerbRe.FindAllString(strings.ReplaceAll(format, "%%", ""), -1)
+	}
+	// Only the built-in catalogs; tests may register partial ones.
+	for locale, catalog := range map[string]map[string]string{"pl": messagesPL} {
+		for code, en := range messagesEN {
+			msg, ok := catalog[code]
+			if !ok {
+				t.Errorf("%s: missing translation for %s", locale, code)
+				continue
+			}
+			if want, got := verbs(en), verbs(msg); !reflect.DeepEqual(want, got) {
+				t.Errorf("%s: %s has verbs %q, English has %q", locale, code, got, want)
+			}
+		}
+		for code := range catalog {
+			if _, ok := messagesEN[code]; !ok {
+				t.Errorf("%s: %s has no English message", locale, code)
+			}
+		}
+	}
+}
@@ -1,26 +1,42 @@
 package dml
 
 import (
-	"fmt"
 	"strconv"
 	"strings"
+	"time"
 )
 
+type Declaration struct {
+	Name   string
+	Type   string
+	Line   int
+	Column int
+	Doc    string
+}
+
 func (c *Config) Parse(content string) error {
 	lines := strings.Split(content, "\n")
 	var multiLineBuffer strings.Builder
 	var isInMultiLine bool
 	var multiLineStart int
+	var doc []string
 
 	for lineNum, line := range lines {
 		originalLine := line
 		line = strings.TrimSpace(line)
 
-		if !isInMultiLine && (line == "" || strings.HasPrefix(line, "//")) {
+		if !isInMultiLine && line == "" {
+			doc = nil
+			continue
+		}
+
+		if !isInMultiLine && strings.HasPrefix(line, "//") {
+			doc = append(doc, strings.TrimSpace(strings.TrimPrefix(line, "//")))
 			continue
 		}
 
 		if !isInMultiLine && strings.HasPrefix(line, "@") {
+			doc = nil
 			if err := c.parseDirective(line, lineNum+1); err != nil {
 				return err
 			}
@@ -46,6 +62,8 @@ func (c *Config) Parse(content string) error {
 				if err := c.parseLine(fullLine, multiLineStart, fullLine); err != nil {
 					return err
 				}
+				c.attachDoc(doc)
+				doc = nil
 				multiLineBuffer.Reset()
 				isInMultiLine = false
 			}
@@ -53,28 +71,18 @@ func (c *Config) Parse(content string) error {
 		}
 
 		if !strings.HasSuffix(line, ";") {
-			return &DMLError{
-				Type:    ErrorTypeSyntax,
-				Message: "Missing semicolon at the end of declaration",
-				Line:    lineNum + 1,
-				Column:  len(line),
-				Context: originalLine,
-			}
+			return c.newError(ErrorTypeSyntax, "MISSING_SEMICOLON", lineNum+1, len(line), originalLine)
 		}
 
 		if err := c.parseLine(line, lineNum+1, originalLine); err != nil {
 			return err
 		}
+		c.attachDoc(doc)
+		doc = nil
 	}
 
 	if isInMultiLine {
-		return &DMLError{
-			Type:    ErrorTypeSyntax,
-			Message: "Unclosed declaration (missing ';')",
-			Line:    multiLineStart,
-			Column:  1,
-			Context: multiLineBuffer.String(),
-		}
+		return c.newError(ErrorTypeSyntax, "UNCLOSED_DECLARATION", multiLineStart, 1, multiLineBuffer.String())
 	}
 
 	return nil
@@ -85,13 +93,7 @@ func (c *Config) parseDirective(line string, lineNum int) error {
 	parts := strings.Fields(line)
 
 	if len(parts) < 2 {
-		return &DMLError{
-			Type:    ErrorTypeValidation,
-			Message: fmt.Sprintf("Invalid directive: @%s", line),
-			Line:    lineNum,
-			Column:  1,
-			Context: line,
-		}
+		return c.newError(ErrorTypeValidation, "INVALID_DIRECTIVE", lineNum, 1, line, line)
 	}
 
 	directive := parts[0]
@@ -101,13 +103,7 @@ func (c *Config) parseDirective(line string, lineNum int) error {
 	case "mapStyle":
 		return c.handleMapStyleDirective(value, lineNum)
 	default:
-		return &DMLError{
-			Type:    ErrorTypeValidation,
-			Message: fmt.Sprintf("Unknown directive: @%s", directive),
-			Line:    lineNum,
-			Column:  1,
-			Context: line,
-		}
+		return c.newError(ErrorTypeValidation, "UNKNOWN_DIRECTIVE", lineNum, 1, line, directive)
 	}
 }
 
@@ -120,13 +116,7 @@ func (c *Config) handleMapStyleDirective(value string, lineNum int) error {
 	case "auto":
 		c.SetMapStyle(MapStyleAuto)
 	default:
-		return &DMLError{
-			Type:    ErrorTypeValidation,
-			Message: fmt.Sprintf("Invalid mapStyle value: %s (expected: json, flat, or auto)", value),
-			Line:    lineNum,
-			Column:  1,
-			Context: value,
-		}
+		return c.newError(ErrorTypeValidation, "INVALID_MAP_STYLE", lineNum, 1, value, value)
 	}
 	return nil
 }
@@ -136,13 +126,7 @@ func (c *Config) parseLine(line string, lineNum int, originalLine string) error
 
 	parts := strings.SplitN(line, "=", 2)
 	if len(parts) != 2 {
-		return &DMLError{
-			Type:    ErrorTypeSyntax,
-			Message: "Missing '=' operator in variable declaration",
-			Line:    lineNum,
-			Column:  1,
-			Context: originalLine,
-		}
+		return c.newError(ErrorTypeSyntax, "MISSING_EQUALS", lineNum, 1, originalLine)
 	}
 
 	declaration := strings.TrimSpace(parts[0])
@@ -150,13 +134,7 @@ func (c *Config) parseLine(line string, lineNum int, originalLine string) error
 
 	declParts := strings.Fields(declaration)
 	if len(declParts) != 2 {
-		return &DMLError{
-			Type:    ErrorTypeValidation,
-			Message: "Invalid variable declaration format. Expected: type name = value",
-			Line:    lineNum,
-			Column:  1,
-			Context: originalLine,
-		}
+		return c.newError(ErrorTypeValidation, "INVALID_DECLARATION", lineNum, 1, originalLine)
 	}
 
 	varType := declParts[0]
@@ -167,13 +145,12 @@ func (c *Config) parseLine(line string, lineNum int, originalLine string) error
 		if col == 0 {
 			col = 1
 		}
-		return &DMLError{
-			Type:    ErrorTypeValidation,
-			Message: "Invalid identifier. Must start with letter or underscore, and contain only letters, digits, underscores, or dots",
-			Line:    lineNum,
-			Column:  col,
-			Context: originalLine,
-		}
+		return c.newError(ErrorTypeValidation, "INVALID_IDENTIFIER", lineNum, col, originalLine)
+	}
+
+	if varType == "default" {
+		c.parseDefault(varName, value, lineNum, originalLine)
+		return nil
 	}
 
 	parsedValue, err := c.parseValue(varType, value, lineNum, 1, originalLine)
@@ -181,11 +158,112 @@ func (c *Config) parseLine(line string, lineNum int, originalLine string) error
 		return err
 	}
 
+	if c.strict {
+		if err := c.checkRedeclaration(varName, lineNum, originalLine); err != nil {
+			return err
+		}
+	}
+
+	col := strings.Index(originalLine, varType) + 1
+	if col == 0 {
+		col = 1
+	}
+	c.decls = append(c.decls, Declaration{
+		Name:   varName,
+		Type:   varType,
+		Line:   lineNum,
+		Column: col,
+	})
+
 	c.Set(varName, parsedValue)
+	c.setType(varName, varType)
 	return nil
 }
 
+// parseDefault handles `default name = value;`, which gives name a value
+// only if no typed declaration sets it, before or after. The value is read
+// like a map entry and its type inferred.
+func (c *Config) parseDefault(name, value string, lineNum int, line string) {
+	col := strings.Index(line, "default") + 1
+	if col == 0 {
+		col = 1
+	}
+	c.decls = append(c.decls, Declaration{Name: name, Type: "default", Line: lineNum, Column: col})
+	if c.Has(name) {
+		return
+	}
+	parsed := c.parseMapValue(value)
+	c.Set(name, parsed)
+	c.setType(name, dmlTypeOf(parsed))
+}
+
+// checkRedeclaration reports, in strict mode, a declaration of name that
+// would overwrite an earlier one: the same key again, a dotted key that lands
+// on a value set before (inside a map literal, or below a scalar), or a key
+// that replaces earlier dotted keys beneath it.
+func (c *Config) checkRedeclaration(name string, lineNum int, line string) error {
+	col := strings.Index(line, name) + 1
+	if col == 0 {
+		col = 1
+	}
+	for i := len(c.decls) - 1; i >= 0; i-- {
+		if d := c.decls[i]; d.Name == name && d.Type != "default" {
+			return c.newError(ErrorTypeValidation, "DUPLICATE_KEY", lineNum, col, line, name, d.Line)
+		}
+	}
+	for i := len(c.decls) - 1; i >= 0; i-- {
+		d := c.decls[i]
+		if d.Type == "default" {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(d.Name, name+"."):
+		case strings.HasPrefix(name, d.Name+".") && c.overwrites(name):
+		default:
+			continue
+		}
+		return c.newError(ErrorTypeValidation, "SHADOWED_KEY", lineNum, col, line, name, d.Name, d.Line)
+	}
+	return nil
+}
+
+// overwrites reports whether setting the dotted key would replace a value:
+// the key itself exists, or one of its parents is not a map.
+func (c *Config) overwrites(key string) bool {
+	if c.Has(key) {
+		return true
+	}
+	parts := strings.Split(key, ".")
+	for i := 1; i < len(parts); i++ {
+		v, ok := c.Get(strings.Join(parts[:i], "."))
+		if !ok {
+			return false
+		}
+		if _, isMap := v.(map[string]any); !isMap {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Config) attachDoc(doc []string) {
+	if len(doc) == 0 || len(c.decls) == 0 {
+		return
+	}
+	c.decls[len(c.decls)-1].Doc = strings.Join(doc, "\n")
+}
+
+// Declarations returns the top-level declarations seen by Parse, in source order.
+func (c *Config) Declarations() []Declaration {
+	out := make([]Declaration, len(c.decls))
+	copy(out, c.decls)
+	return out
+}
+
 func (c *Config) parseValue(varType, value string, lineNum, col int, line string) (interface{}, error) {
+	if template, ok := envTemplate(varType, value); ok {
+		return template, nil
+	}
 	switch varType {
 	case "string":
 		return c.parseString(value, lineNum, col, line)
@@ -199,26 +277,16 @@ func (c *Config) parseValue(varType, value string, lineNum, col int, line string
 		return c.parseList(value, lineNum, col, line)
 	case "map":
 		return c.parseMap(value, lineNum, col, line)
+	case "duration":
+		return c.parseDuration(value, lineNum, col, line)
 	default:
-		return nil, &DMLError{
-			Type:    ErrorTypeValidation,
-			Message: fmt.Sprintf("Unknown type: %s", varType),
-			Line:    lineNum,
-			Column:  col,
-			Context: line,
-		}
+		return nil, c.newError(ErrorTypeValidation, "UNKNOWN_TYPE", lineNum, col, line, varType)
 	}
 }
 
 func (c *Config) parseString(value string, lineNum, col int, line string) (string, error) {
 	if !strings.HasPrefix(value, `"`) || !strings.HasSuffix(value, `"`) {
-		return "", &DMLError{
-			Type:    ErrorTypeType,
-			Message: "String must be enclosed in double quotes",
-			Line:    lineNum,
-			Column:  col,
-			Context: line,
-		}
+		return "", c.newError(ErrorTypeType, "INVALID_STRING", lineNum, col, line)
 	}
 	return strings.Trim(value, `"`), nil
 }
@@ -226,13 +294,7 @@ func (c *Config) parseString(value string, lineNum, col int, line string) (strin
 func (c *Config) parseInt(value string, lineNum, col int, line string) (int, error) {
 	val, err := strconv.Atoi(value)
 	if err != nil {
-		return 0, &DMLError{
-			Type:    ErrorTypeType,
-			Message: fmt.Sprintf("Invalid integer value: %s", value),
-			Line:    lineNum,
-			Column:  col,
-			Context: line,
-		}
+		return 0, c.newError(ErrorTypeType, "INVALID_INT", lineNum, col, line, value)
 	}
 	return val, nil
 }
@@ -240,13 +302,7 @@ func (c *Config) parseInt(value string, lineNum, col int, line string) (int, err
 func (c *Config) parseFloat(value string, lineNum, col int, line string) (float64, error) {
 	val, err := strconv.ParseFloat(value, 64)
 	if err != nil {
-		return 0, &DMLError{
-			Type:    ErrorTypeType,
-			Message: fmt.Sprintf("Invalid float value: %s", value),
-			Line:    lineNum,
-			Column:  col,
-			Context: line,
-		}
+		return 0, c.newError(ErrorTypeType, "INVALID_FLOAT", lineNum, col, line, value)
 	}
 	return val, nil
 }
@@ -258,35 +314,30 @@ func (c *Config) parseBool(value string, lineNum, col int, line string) (bool, e
 	case "false":
 		return false, nil
 	default:
-		return false, &DMLError{
-			Type:    ErrorTypeType,
-			Message: "Boolean must be 'true' or 'false'",
-			Line:    lineNum,
-			Column:  col,
-			Context: line,
-		}
+		return false, c.newError(ErrorTypeType, "INVALID_BOOL", lineNum, col, line)
+	}
+}
+
+func (c *Config) parseDuration(value string, lineNum, col int, line string) (time.Duration, error) {
+	val, err := time.ParseDuration(strings.Trim(value, `"`))
+	if err != nil {
+		return 0, c.newError(ErrorTypeType, "INVALID_DURATION", lineNum, col, line, value)
 	}
+	return val, nil
 }
 
 func (c *Config) parseList(value string, lineNum, col int, line string) ([]interface{}, error) {
 	if !strings.HasPrefix(value, "[") || !strings.HasSuffix(value, "]") {
-		return nil, &DMLError{
-			Type:    ErrorTypeType,
-			Message: "List must be enclosed in square brackets []",
-			Line:    lineNum,
-			Column:  col,
-			Context: line,
-		}
+		return nil, c.newError(ErrorTypeType, "INVALID_LIST", lineNum, col, line)
 	}
 
-	content := strings.Trim(value, "[]")
-	content = strings.TrimSpace(content)
+	content := strings.TrimSpace(value[1 : len(value)-1])
 
 	if content == "" {
 		return []interface{}{}, nil
 	}
 
-	items := strings.Split(content, ",")
+	items := c.smartSplit(content, ',')
 	result := make([]interface{}, 0, len(items))
 
 	for _, item := range items {
@@ -294,18 +345,7 @@ func (c *Config) parseList(value string, lineNum, col int, line string) ([]inter
 		if item == "" {
 			continue
 		}
-
-		if strings.HasPrefix(item, `"`) && strings.HasSuffix(item, `"`) {
-			result = append(result, strings.Trim(item, `"`))
-		} else if val, err := strconv.Atoi(item); err == nil {
-			result = append(result, val)
-		} else if val, err := strconv.ParseFloat(item, 64); err == nil {
-			result = append(result, val)
-		} else if item == "true" || item == "false" {
-			result = append(result, item == "true")
-		} else {
-			result = append(result, item)
-		}
+		result = append(result, c.parseMapValue(item))
 	}
 
 	return result, nil
@@ -313,17 +353,10 @@ func (c *Config) parseList(value string, lineNum, col int, line string) ([]inter
 
 func (c *Config) parseMap(value string, lineNum, col int, line string) (map[string]interface{}, error) {
 	if !strings.HasPrefix(value, "{") || !strings.HasSuffix(value, "}") {
-		return nil, &DMLError{
-			Type:    ErrorTypeType,
-			Message: "Map must be enclosed in curly braces {}",
-			Line:    lineNum,
-			Column:  col,
-			Context: line,
-		}
+		return nil, c.newError(ErrorTypeType, "INVALID_MAP", lineNum, col, line)
 	}
 
-	content := strings.Trim(value, "{}")
-	content = strings.TrimSpace(content)
+	content := strings.TrimSpace(value[1 : len(value)-1])
 
 	if content == "" {
 		return map[string]interface{}{}, nil
@@ -340,13 +373,7 @@ func (c *Config) parseMap(value string, lineNum, col int, line string) (map[stri
 
 		parts := strings.SplitN(pair, ":", 2)
 		if len(parts) != 2 {
-			return nil, &DMLError{
-				Type:    ErrorTypeType,
-				Message: "Map entries must be in 'key: value' format",
-				Line:    lineNum,
-				Column:  col,
-				Context: line,
-			}
+			return nil, c.newError(ErrorTypeType, "INVALID_MAP_ENTRY", lineNum, col, line)
 		}
 
 		key := strings.Trim(strings.TrimSpace(parts[0]), `"`)
@@ -395,6 +422,13 @@ func (c *Config) smartSplit(s string, delimiter rune) []string {
 	return result
 }
 
+// ParseValue interprets a DML literal the way map entries are read: quoted
+// strings, booleans, integers, floats, lists and maps. Anything else is
+// returned as a plain string.
+func ParseValue(text string) any {
+	return (&Config{}).parseMapValue(text)
+}
+
 func (c *Config) parseMapValue(val string) interface{} {
 	val = strings.TrimSpace(val)
 
@@ -118,3 +118,36 @@ string name = "test";`
 		}
 	}
 }
+
+func TestParseDeclarations_DocComments(t *testing.T) {
+	content := `// Listening port
+// for the public API
+int port = 8080;
+
+// detached comment
+
+string name = "api";
+duration timeout = "15s";`
+
+	cfg := New()
+	if err := cfg.Parse(content); err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+
+	decls := cfg.Declarations()
+	if len(decls) != 3 {
+		t.Fatalf("expected 3 declarations, got %d", len(decls))
+	}
+	if decls[0].Name != "port" || decls[0].Type != "int" || decls[0].Line != 3 {
+		t.Errorf("unexpected first declaration: %+v", decls[0])
+	}
+	if decls[0].Doc != "Listening port\nfor the public API" {
+		t.Errorf("unexpected doc: %q", decls[0].Doc)
+	}
+	if decls[1].Doc != "" {
+		t.Errorf("expected detached comment to be dropped, got %q", decls[1].Doc)
+	}
+	if got := cfg.GetDuration("timeout"); got.String() != "15s" {
+		t.Errorf("expected timeout=15s, got %v", got)
+	}
+}
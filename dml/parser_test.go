@@ -2,6 +2,7 @@ package dml
 
 import (
     "testing"
+    "time"
 )
 
 func TestParse_ValidString(t *testing.T) {
@@ -255,4 +256,114 @@ func TestIsValidIdentifier(t *testing.T) {
             t.Errorf("isValidIdentifier(%q) = %v, want %v", tt.name, result, tt.valid)
         }
     }
-}
\ No newline at end of file
+}
+
+func TestParse_StrictRedeclaration(t *testing.T) {
+    tests := []struct {
+        name string
+        src  string
+        code string
+        line int
+    }{
+        {"duplicate declaration", "int port = 1;\nint port = 2;\n", "DUPLICATE_KEY", 2},
+        {"dotted key into map", "map server = {\"port\": 80};\nint server.port = 8080;\n", "SHADOWED_KEY", 2},
+        {"dotted key below scalar", "string server = \"x\";\nint server.port = 8080;\n", "SHADOWED_KEY", 2},
+        {"map replaces dotted keys", "int server.port = 8080;\nmap server = {\"host\": \"x\"};\n", "SHADOWED_KEY", 2},
+        {"new key next to map", "map server = {\"host\": \"x\"};\nint server.port = 8080;\n", "", 0},
+        {"default then declaration", "default port = 80;\nint port = 8080;\n", "", 0},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            if err := New().Parse(tt.src); err != nil {
+                t.Fatalf("default mode must accept the file: %v", err)
+            }
+
+            c := New()
+            c.SetStrict(true)
+            err := c.Parse(tt.src)
+            if tt.code == "" {
+                if err != nil {
+                    t.Fatalf("unexpected error: %v", err)
+                }
+                return
+            }
+            dmlErr, ok := err.(*DMLError)
+            if !ok {
+                t.Fatalf("expected DMLError, got %v", err)
+            }
+            if dmlErr.Code != tt.code || dmlErr.Line != tt.line {
+                t.Errorf("got %s at line %d, want %s at line %d", dmlErr.Code, dmlErr.Line, tt.code, tt.line)
+            }
+        })
+    }
+}
+
+func TestParse_DefaultDeclarations(t *testing.T) {
+    c := New()
+    src := "default port = 80;\nint port = 8080;\ndefault host = \"localhost\";\nstring name = \"api\";\ndefault name = \"other\";\n"
+    if err := c.Parse(src); err != nil {
+        t.Fatalf("Parse: %v", err)
+    }
+
+    if got := c.GetInt("port"); got != 8080 {
+        t.Errorf("expected the declaration to replace the default, got %d", got)
+    }
+    if got := c.GetString("host"); got != "localhost" {
+        t.Errorf("expected the default for an undeclared key, got %q", got)
+    }
+    if got := c.GetString("name"); got != "api" {
+        t.Errorf("expected a later default not to replace a declaration, got %q", got)
+    }
+    if typ, _ := c.DeclaredType("host"); typ != "string" {
+        t.Errorf("expected the type of a default to be inferred, got %q", typ)
+    }
+}
+
+func TestParse_ListItems(t *testing.T) {
+    cfg := New()
+    err := cfg.Parse(`list items = ["a, b", 2, 1.5, true, {"host": "x", "port": 80}, [1, 2]];`)
+
+    if err != nil {
+        t.Fatalf("Unexpected error: %v", err)
+    }
+
+    list := cfg.GetList("items")
+    if len(list) != 6 {
+        t.Fatalf("Expected 6 items, got %d: %v", len(list), list)
+    }
+    if list[0] != "a, b" {
+        t.Errorf("Expected a comma inside quotes to stay in the item, got %v", list[0])
+    }
+    if list[1] != 2 || list[2] != 1.5 || list[3] != true {
+        t.Errorf("Expected typed scalars, got %v", list[1:4])
+    }
+    if m, ok := list[4].(map[string]interface{}); !ok || m["host"] != "x" || m["port"] != 80 {
+        t.Errorf("Expected a nested map, got %#v", list[4])
+    }
+    if nested, ok := list[5].([]interface{}); !ok || len(nested) != 2 {
+        t.Errorf("Expected a nested list, got %#v", list[5])
+    }
+}
+
+func TestParse_Duration(t *testing.T) {
+    cfg := New()
+    err := cfg.Parse(`duration timeout = "1m30s";`)
+
+    if err != nil {
+        t.Fatalf("Unexpected error: %v", err)
+    }
+
+    if v, ok := cfg.Get("timeout"); !ok || v != 90*time.Second {
+        t.Errorf("Expected 1m30s as a time.Duration, got %#v", v)
+    }
+
+    err = New().Parse(`duration timeout = "soon";`)
+    dmlErr, ok := err.(*DMLError)
+    if !ok {
+        t.Fatalf("Expected DMLError, got %v", err)
+    }
+    if dmlErr.Type != ErrorTypeType || dmlErr.Code != "INVALID_DURATION" {
+        t.Errorf("Expected an INVALID_DURATION type error, got %+v", dmlErr)
+    }
+}
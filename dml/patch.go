@@ -0,0 +1,393 @@
+package dml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SetSchema registers typed rules, in the form accepted by
+// ValidateRequiredTyped, that ApplyPatch and ApplyMergePatch check before
+// committing a change. Pass nil to remove the schema.
+func (c *Config) SetSchema(rules map[string]string) {
+	c.schema = rules
+}
+
+type patchOp struct {
+	Op       string
+	Path     string
+	From     string
+	Value    any
+	hasValue bool
+}
+
+// ApplyPatch applies an RFC 6902 JSON Patch. JSON Pointers address dotted
+// keys and list indices, so "/servers/0/host" is servers[0].host. The patch is
+// atomic: if any operation fails, or the result does not satisfy the schema
+// registered with SetSchema, the config is left unchanged.
+func (c *Config) ApplyPatch(patch []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(patch))
+	dec.UseNumber()
+
+	var raw []map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return fmt.Errorf("invalid JSON patch: %w", err)
+	}
+
+	next := c.Clone()
+	var doc any = next.data
+	for i, fields := range raw {
+		op, err := decodePatchOp(fields)
+		if err != nil {
+			return fmt.Errorf("patch operation %d: %w", i, err)
+		}
+		if doc, err = next.applyPatchOp(doc, op); err != nil {
+			return fmt.Errorf("patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+
+	root, ok := doc.(map[string]any)
+	if !ok {
+		return fmt.Errorf("patch would replace the config with a %s", dmlTypeOf(doc))
+	}
+	next.data = root
+	return c.commit(next)
+}
+
+// ApplyMergePatch applies an RFC 7396 JSON Merge Patch: objects are merged
+// recursively, null removes a key and any other value replaces it. Like
+// ApplyPatch it is atomic and checked against the registered schema.
+func (c *Config) ApplyMergePatch(patch []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(patch))
+	dec.UseNumber()
+
+	var raw map[string]any
+	if err := dec.Decode(&raw); err != nil {
+		return fmt.Errorf("invalid JSON merge patch: %w", err)
+	}
+
+	next := c.Clone()
+	if err := next.mergePatch("", next.data, raw); err != nil {
+		return err
+	}
+	return c.commit(next)
+}
+
+// commit validates next and, if it passes, moves its data into c.
+func (c *Config) commit(next *Config) error {
+	next.syncTypes()
+	if next.schema != nil {
+		if err := next.ValidateRequiredTyped(next.schema); err != nil {
+			return fmt.Errorf("patch rejected by schema: %w", err)
+		}
+	}
+	c.data = next.data
+	c.types = next.types
+	return nil
+}
+
+func (c *Config) mergePatch(prefix string, target, patch map[string]any) error {
+	for k, v := range patch {
+		key := joinKey(prefix, k)
+		if v == nil {
+			delete(target, k)
+			continue
+		}
+		if sub, ok := v.(map[string]any); ok {
+			existing, isMap := target[k].(map[string]any)
+			if !isMap {
+				existing = make(map[string]any)
+				target[k] = existing
+			}
+			if err := c.mergePatch(key, existing, sub); err != nil {
+				return err
+			}
+			continue
+		}
+		val, ok, err := c.fromJSONValue(key, v)
+		if err != nil {
+			return err
+		}
+		if ok {
+			target[k] = val
+		}
+	}
+	return nil
+}
+
+func decodePatchOp(fields map[string]any) (patchOp, error) {
+	var op patchOp
+	var ok bool
+	if op.Op, ok = fields["op"].(string); !ok {
+		return op, fmt.Errorf("missing \"op\"")
+	}
+	if op.Path, ok = fields["path"].(string); !ok {
+		return op, fmt.Errorf("missing \"path\"")
+	}
+	if from, exists := fields["from"]; exists {
+		if op.From, ok = from.(string); !ok {
+			return op, fmt.Errorf("\"from\" must be a string")
+		}
+	}
+	op.Value, op.hasValue = fields["value"]
+	return op, nil
+}
+
+func (c *Config) applyPatchOp(doc any, op patchOp) (any, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	needsValue := op.Op == "add" || op.Op == "replace" || op.Op == "test"
+	if needsValue && !op.hasValue {
+		return nil, fmt.Errorf("missing \"value\"")
+	}
+	var value any
+	if needsValue {
+		converted, ok, err := c.fromJSONValue(pointerKey(path), op.Value)
+		if err != nil {
+			return nil, err
+		}
+		if !ok && op.Op != "test" {
+			return nil, fmt.Errorf("null values are not supported")
+		}
+		value = converted
+	}
+
+	switch op.Op {
+	case "add":
+		return pointerAdd(doc, path, value)
+	case "remove":
+		doc, _, err := pointerRemove(doc, path)
+		return doc, err
+	case "replace":
+		if _, err := pointerGet(doc, path); err != nil || len(path) == 0 {
+			return value, err
+		}
+		doc, _, err := pointerRemove(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, value)
+	case "move", "copy":
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" && len(path) > len(from) && strings.HasPrefix(op.Path, op.From+"/") {
+			return nil, fmt.Errorf("cannot move a value into itself")
+		}
+		moved, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == "move" {
+			if doc, _, err = pointerRemove(doc, from); err != nil {
+				return nil, err
+			}
+		} else {
+			moved = deepCopy(moved)
+		}
+		return pointerAdd(doc, path, moved)
+	case "test":
+		actual, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !patchValuesEqual(actual, value) {
+			return nil, fmt.Errorf("test failed: value is %v", actual)
+		}
+		return doc, nil
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference
+// tokens. The empty pointer refers to the whole config.
+func parsePointer(p string) ([]string, error) {
+	if p == "" {
+		return nil, nil
+	}
+	if p[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q", p)
+	}
+	tokens := strings.Split(p[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens, nil
+}
+
+// pointerKey renders pointer tokens as the dotted key used for type lookups.
+func pointerKey(tokens []string) string {
+	return strings.Join(tokens, ".")
+}
+
+func listIndex(list []any, token string, allowEnd bool) (int, error) {
+	if allowEnd && token == "-" {
+		return len(list), nil
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || (token != "0" && token[0] == '0') {
+		return 0, fmt.Errorf("invalid list index %q", token)
+	}
+	limit := len(list) - 1
+	if allowEnd {
+		limit = len(list)
+	}
+	if i > limit {
+		return 0, fmt.Errorf("list index %d out of range", i)
+	}
+	return i, nil
+}
+
+func pointerGet(node any, tokens []string) (any, error) {
+	for _, t := range tokens {
+		switch n := node.(type) {
+		case map[string]any:
+			v, ok := n[t]
+			if !ok {
+				return nil, fmt.Errorf("key %q not found", t)
+			}
+			node = v
+		case []any:
+			i, err := listIndex(n, t, false)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot index %s with %q", dmlTypeOf(node), t)
+		}
+	}
+	return node, nil
+}
+
+// pointerAdd returns node with value added at tokens. Lists are returned as
+// new slices, so callers must store the result back into the parent.
+func pointerAdd(node any, tokens []string, value any) (any, error) {
+	if len(tokens) == 0 {
+		return value, nil
+	}
+	t, rest := tokens[0], tokens[1:]
+
+	switch n := node.(type) {
+	case map[string]any:
+		if len(rest) == 0 {
+			n[t] = value
+			return n, nil
+		}
+		child, ok := n[t]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found", t)
+		}
+		updated, err := pointerAdd(child, rest, value)
+		if err != nil {
+			return nil, err
+		}
+		n[t] = updated
+		return n, nil
+	case []any:
+		i, err := listIndex(n, t, len(rest) == 0)
+		if err != nil {
+			return nil, err
+		}
+		if len(rest) == 0 {
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		}
+		updated, err := pointerAdd(n[i], rest, value)
+		if err != nil {
+			return nil, err
+		}
+		n[i] = updated
+		return n, nil
+	}
+	return nil, fmt.Errorf("cannot index %s with %q", dmlTypeOf(node), t)
+}
+
+// pointerRemove returns node without the value at tokens, and that value.
+func pointerRemove(node any, tokens []string) (any, any, error) {
+	if len(tokens) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the whole config")
+	}
+	t, rest := tokens[0], tokens[1:]
+
+	switch n := node.(type) {
+	case map[string]any:
+		child, ok := n[t]
+		if !ok {
+			return nil, nil, fmt.Errorf("key %q not found", t)
+		}
+		if len(rest) == 0 {
+			delete(n, t)
+			return n, child, nil
+		}
+		updated, removed, err := pointerRemove(child, rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[t] = updated
+		return n, removed, nil
+	case []any:
+		i, err := listIndex(n, t, false)
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(rest) == 0 {
+			removed := n[i]
+			return append(n[:i:i], n[i+1:]...), removed, nil
+		}
+		updated, removed, err := pointerRemove(n[i], rest)
+		if err != nil {
+			return nil, nil, err
+		}
+		n[i] = updated
+		return n, removed, nil
+	}
+	return nil, nil, fmt.Errorf("cannot index %s with %q", dmlTypeOf(node), t)
+}
+
+// patchValuesEqual compares values as JSON does, so 1 and 1.0 are equal.
+func patchValuesEqual(a, b any) bool {
+	switch x := a.(type) {
+	case int:
+		if y, ok := b.(float64); ok {
+			return float64(x) == y
+		}
+	case float64:
+		if y, ok := b.(int); ok {
+			return x == float64(y)
+		}
+	case []any:
+		y, ok := b.([]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !patchValuesEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		y, ok := b.(map[string]any)
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for k, v := range x {
+			w, exists := y[k]
+			if !exists || !patchValuesEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
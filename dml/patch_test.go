@@ -0,0 +1,128 @@
+package dml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const patchBase = `map db = {"host": "localhost", "pool": 5};
+list servers = [{"host": "a", "port": 80}, {"host": "b", "port": 81}];
+float ratio = 0.5;
+int port = 8080;`
+
+func TestApplyPatch_Operations(t *testing.T) {
+	cfg := mustParse(t, patchBase)
+	err := cfg.ApplyPatch([]byte(`[
+		{"op": "test", "path": "/port", "value": 8080},
+		{"op": "replace", "path": "/db/pool", "value": 10},
+		{"op": "add", "path": "/servers/-", "value": {"host": "c", "port": 82}},
+		{"op": "remove", "path": "/servers/0"},
+		{"op": "replace", "path": "/servers/0/host", "value": "b2"},
+		{"op": "copy", "from": "/db/host", "path": "/db/replica"},
+		{"op": "move", "from": "/port", "path": "/listen"},
+		{"op": "replace", "path": "/ratio", "value": 1},
+		{"op": "add", "path": "/db/ssl~1tls", "value": true}
+	]`))
+	if err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+
+	if v, _ := cfg.Get("db.pool"); v != 10 {
+		t.Errorf("expected db.pool int 10, got %#v", v)
+	}
+	if cfg.GetString("db.replica") != "localhost" || !cfg.GetBool("db.ssl/tls") {
+		t.Errorf("unexpected db: %#v", cfg.GetMap("db"))
+	}
+	servers := cfg.GetList("servers")
+	want := []any{
+		map[string]any{"host": "b2", "port": 81},
+		map[string]any{"host": "c", "port": 82},
+	}
+	if !reflect.DeepEqual(servers, want) {
+		t.Errorf("expected servers %v, got %v", want, servers)
+	}
+	if cfg.Has("port") || cfg.GetInt("listen") != 8080 {
+		t.Errorf("expected port moved to listen, got keys %v", cfg.Keys())
+	}
+	if v, _ := cfg.Get("ratio"); v != 1.0 {
+		t.Errorf("expected ratio to stay a float, got %#v", v)
+	}
+}
+
+func TestApplyPatch_Atomic(t *testing.T) {
+	cfg := mustParse(t, patchBase)
+	err := cfg.ApplyPatch([]byte(`[
+		{"op": "replace", "path": "/port", "value": 1},
+		{"op": "remove", "path": "/missing"}
+	]`))
+	if err == nil || !strings.Contains(err.Error(), "operation 1") {
+		t.Fatalf("expected failure in operation 1, got %v", err)
+	}
+	if cfg.GetInt("port") != 8080 {
+		t.Errorf("failed patch modified the config: port=%d", cfg.GetInt("port"))
+	}
+
+	if err := cfg.ApplyPatch([]byte(`[{"op": "test", "path": "/port", "value": 1}]`)); err == nil {
+		t.Error("expected failing test operation to return an error")
+	}
+	if err := cfg.ApplyPatch([]byte(`[{"op": "add", "path": "/servers/5", "value": 1}]`)); err == nil {
+		t.Error("expected out of range index to return an error")
+	}
+}
+
+func TestApplyPatch_Schema(t *testing.T) {
+	cfg := mustParse(t, patchBase)
+	cfg.SetSchema(map[string]string{"port": "int", "db": "map"})
+
+	if err := cfg.ApplyPatch([]byte(`[{"op": "replace", "path": "/port", "value": "http"}]`)); err == nil {
+		t.Fatal("expected schema violation")
+	}
+	if err := cfg.ApplyMergePatch([]byte(`{"db": null}`)); err == nil {
+		t.Fatal("expected schema violation for removed required key")
+	}
+	if cfg.GetInt("port") != 8080 || !cfg.Has("db") {
+		t.Error("rejected patches modified the config")
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	cfg := mustParse(t, patchBase)
+	err := cfg.ApplyMergePatch([]byte(`{"db": {"pool": 20, "host": null, "tls": {"enabled": true}}, "ratio": 2, "servers": ["x"]}`))
+	if err != nil {
+		t.Fatalf("ApplyMergePatch: %v", err)
+	}
+
+	want := map[string]any{"pool": 20, "tls": map[string]any{"enabled": true}}
+	if got := cfg.GetMap("db"); !reflect.DeepEqual(got, want) {
+		t.Errorf("expected db %v, got %v", want, got)
+	}
+	if v, _ := cfg.Get("ratio"); v != 2.0 {
+		t.Errorf("expected ratio float 2, got %#v", v)
+	}
+	if !reflect.DeepEqual(cfg.GetList("servers"), []any{"x"}) {
+		t.Errorf("expected lists to be replaced, got %v", cfg.GetList("servers"))
+	}
+	if cfg.GetInt("port") != 8080 {
+		t.Error("keys absent from the patch must be kept")
+	}
+}
+
+func TestApplyPatch_DiffRoundTrip(t *testing.T) {
+	a := mustParse(t, patchBase)
+	b := mustParse(t, `map db = {"host": "db", "tls": {"enabled": true}};
+list servers = [{"host": "a", "port": 80}];
+string ratio = "half";
+bool debug = true;`)
+
+	patch, err := JSONPatch(Diff(a, b))
+	if err != nil {
+		t.Fatalf("JSONPatch: %v", err)
+	}
+	if err := a.ApplyPatch(patch); err != nil {
+		t.Fatalf("ApplyPatch: %v\n%s", err, patch)
+	}
+	if changes := Diff(a, b); len(changes) != 0 {
+		t.Errorf("expected no differences after applying the diff, got %+v", changes)
+	}
+}
@@ -0,0 +1,502 @@
+package dml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Path expressions extend dotted keys with list indices, wildcards and
+// filters:
+//
+//	servers[1].host
+//	servers[-1]                   last item
+//	servers[*].host               every item
+//	db.*                          every value of a map
+//	servers[?(@.region=="eu")]    items matching a comparison
+//	labels["app.kubernetes.io"]   keys that contain dots
+
+type segmentKind int
+
+const (
+	segmentField segmentKind = iota
+	segmentIndex
+	segmentWildcard
+	segmentFilter
+)
+
+type pathSegment struct {
+	kind   segmentKind
+	name   string
+	index  int
+	filter *pathFilter
+}
+
+type pathFilter struct {
+	field []string
+	op    string
+	value any
+}
+
+type pathError struct {
+	expr string
+	pos  int
+	msg  string
+}
+
+func (e *pathError) Error() string {
+	return fmt.Sprintf("invalid path %q at offset %d: %s", e.expr, e.pos, e.msg)
+}
+
+// Query evaluates a path expression and returns every matching value in
+// document order. No match is not an error; a malformed expression is.
+func (c *Config) Query(expr string) ([]any, error) {
+	segs, err := parsePath(expr)
+	if err != nil {
+		return nil, err
+	}
+	return evalPath(c.data, segs), nil
+}
+
+// isPathExpr reports whether key needs the extended path syntax rather than
+// plain dotted lookup.
+func isPathExpr(key string) bool {
+	return strings.ContainsAny(key, "[*")
+}
+
+// getPath resolves a path expression that addresses exactly one value.
+func (c *Config) getPath(key string) (any, bool) {
+	segs, err := parsePath(key)
+	if err != nil || !singular(segs) {
+		return nil, false
+	}
+	matches := evalPath(c.data, segs)
+	if len(matches) != 1 {
+		return nil, false
+	}
+	return matches[0], true
+}
+
+// SetPath is Set for path expressions such as "servers[1].host", reporting
+// why a value could not be set: a malformed path, a wildcard or filter that
+// may match more than one value, or a list or index that does not exist.
+// Missing map keys are created; an index equal to the list length appends.
+// Plain dotted keys are set as by Set.
+func (c *Config) SetPath(key string, value any) error {
+	if !isPathExpr(key) {
+		c.Set(key, value)
+		return nil
+	}
+	segs, err := parsePath(key)
+	if err != nil {
+		return err
+	}
+	if !singular(segs) {
+		return fmt.Errorf("cannot set '%s': path matches more than one value", key)
+	}
+	updated, err := setAtPath(c.data, segs, value, "")
+	if err != nil {
+		return fmt.Errorf("cannot set '%s': %w", key, err)
+	}
+	c.data = updated.(map[string]any)
+	return nil
+}
+
+func singular(segs []pathSegment) bool {
+	for _, s := range segs {
+		if s.kind == segmentWildcard || s.kind == segmentFilter {
+			return false
+		}
+	}
+	return true
+}
+
+// setAtPath sets value below node, which is reached by the path at. Nothing
+// is changed when it returns an error.
+func setAtPath(node any, segs []pathSegment, value any, at string) (any, error) {
+	if len(segs) == 0 {
+		return value, nil
+	}
+	seg, rest := segs[0], segs[1:]
+
+	switch seg.kind {
+	case segmentField:
+		m, ok := node.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s is a %s, not a map", at, dmlTypeOf(node))
+		}
+		path := joinKey(at, seg.name)
+		child, exists := m[seg.name]
+		if !exists && len(rest) > 0 {
+			if rest[0].kind != segmentField {
+				return nil, fmt.Errorf("%s does not exist", path)
+			}
+			child = make(map[string]any)
+		}
+		updated, err := setAtPath(child, rest, value, path)
+		if err != nil {
+			return nil, err
+		}
+		m[seg.name] = updated
+		return m, nil
+	case segmentIndex:
+		list, ok := node.([]any)
+		if !ok {
+			return nil, fmt.Errorf("%s is a %s, not a list", at, dmlTypeOf(node))
+		}
+		i := seg.index
+		if i < 0 {
+			i += len(list)
+		}
+		switch {
+		case i == len(list) && len(rest) == 0:
+			return append(list, value), nil
+		case i < 0 || i >= len(list):
+			return nil, fmt.Errorf("index %d is out of range for %s, which has %d items", seg.index, at, len(list))
+		}
+		updated, err := setAtPath(list[i], rest, value, fmt.Sprintf("%s[%d]", at, seg.index))
+		if err != nil {
+			return nil, err
+		}
+		list[i] = updated
+		return list, nil
+	}
+	return nil, fmt.Errorf("path matches more than one value")
+}
+
+func evalPath(root map[string]any, segs []pathSegment) []any {
+	nodes := []any{root}
+	for _, seg := range segs {
+		var next []any
+		for _, node := range nodes {
+			next = append(next, seg.apply(node)...)
+		}
+		nodes = next
+	}
+	if nodes == nil {
+		return []any{}
+	}
+	return nodes
+}
+
+func (s pathSegment) apply(node any) []any {
+	switch s.kind {
+	case segmentField:
+		if m, ok := node.(map[string]any); ok {
+			if v, exists := m[s.name]; exists {
+				return []any{v}
+			}
+		}
+	case segmentIndex:
+		if list, ok := node.([]any); ok {
+			i := s.index
+			if i < 0 {
+				i += len(list)
+			}
+			if i >= 0 && i < len(list) {
+				return []any{list[i]}
+			}
+		}
+	case segmentWildcard:
+		switch n := node.(type) {
+		case []any:
+			return append([]any(nil), n...)
+		case map[string]any:
+			out := make([]any, 0, len(n))
+			for _, k := range sortedMapKeys(n) {
+				out = append(out, n[k])
+			}
+			return out
+		}
+	case segmentFilter:
+		var items []any
+		switch n := node.(type) {
+		case []any:
+			items = n
+		case map[string]any:
+			for _, k := range sortedMapKeys(n) {
+				items = append(items, n[k])
+			}
+		}
+		var out []any
+		for _, item := range items {
+			if s.filter.match(item) {
+				out = append(out, item)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func (f *pathFilter) match(item any) bool {
+	val := item
+	for _, name := range f.field {
+		m, ok := val.(map[string]any)
+		if !ok {
+			return false
+		}
+		if val, ok = m[name]; !ok {
+			return false
+		}
+	}
+	if f.op == "" {
+		return true
+	}
+
+	if a, ok := toFloat(val); ok {
+		if b, ok := toFloat(f.value); ok {
+			return compareOrdered(a, b, f.op)
+		}
+	}
+	if a, ok := val.(string); ok {
+		if b, ok := f.value.(string); ok {
+			return compareOrdered(a, b, f.op)
+		}
+	}
+	switch f.op {
+	case "==":
+		return val == f.value
+	case "!=":
+		return val != f.value
+	}
+	return false
+}
+
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func compareOrdered[T float64 | string](a, b T, op string) bool {
+	switch op {
+	case "==":
+		return a == b
+	case "!=":
+		return a != b
+	case "<":
+		return a < b
+	case "<=":
+		return a <= b
+	case ">":
+		return a > b
+	case ">=":
+		return a >= b
+	}
+	return false
+}
+
+type pathParser struct {
+	expr string
+	pos  int
+}
+
+func parsePath(expr string) ([]pathSegment, error) {
+	p := &pathParser{expr: expr}
+	var segs []pathSegment
+
+	if expr == "" {
+		return nil, p.errorf("empty path")
+	}
+
+	for p.pos < len(expr) {
+		switch expr[p.pos] {
+		case '[':
+			seg, err := p.bracket()
+			if err != nil {
+				return nil, err
+			}
+			segs = append(segs, seg)
+		case '.':
+			if len(segs) == 0 || p.pos+1 >= len(expr) || expr[p.pos+1] == '.' || expr[p.pos+1] == '[' {
+				return nil, p.errorf("unexpected '.'")
+			}
+			p.pos++
+		default:
+			if len(segs) > 0 && expr[p.pos-1] != '.' {
+				return nil, p.errorf("expected '.' or '['")
+			}
+			if expr[p.pos] == '*' {
+				p.pos++
+				segs = append(segs, pathSegment{kind: segmentWildcard})
+				continue
+			}
+			name := p.ident()
+			if name == "" {
+				return nil, p.errorf("expected key")
+			}
+			segs = append(segs, pathSegment{kind: segmentField, name: name})
+		}
+	}
+	return segs, nil
+}
+
+func (p *pathParser) errorf(format string, args ...any) error {
+	return &pathError{expr: p.expr, pos: p.pos, msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *pathParser) ident() string {
+	start := p.pos
+	for p.pos < len(p.expr) && !strings.ContainsRune(".[]()=!<> \t", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+	return p.expr[start:p.pos]
+}
+
+func (p *pathParser) skipSpaces() {
+	for p.pos < len(p.expr) && (p.expr[p.pos] == ' ' || p.expr[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+func (p *pathParser) expect(s string) error {
+	p.skipSpaces()
+	if !strings.HasPrefix(p.expr[p.pos:], s) {
+		return p.errorf("expected %q", s)
+	}
+	p.pos += len(s)
+	return nil
+}
+
+// bracket parses [n], [*], ["key"] and [?(...)].
+func (p *pathParser) bracket() (pathSegment, error) {
+	p.pos++
+	p.skipSpaces()
+	if p.pos >= len(p.expr) {
+		return pathSegment{}, p.errorf("unclosed '['")
+	}
+
+	var seg pathSegment
+	switch c := p.expr[p.pos]; {
+	case c == '*':
+		p.pos++
+		seg.kind = segmentWildcard
+	case c == '?':
+		p.pos++
+		f, err := p.filter()
+		if err != nil {
+			return seg, err
+		}
+		seg.kind, seg.filter = segmentFilter, f
+	case c == '"' || c == '\'':
+		s, err := p.quoted()
+		if err != nil {
+			return seg, err
+		}
+		seg.kind, seg.name = segmentField, s
+	default:
+		start := p.pos
+		if c == '-' {
+			p.pos++
+		}
+		for p.pos < len(p.expr) && p.expr[p.pos] >= '0' && p.expr[p.pos] <= '9' {
+			p.pos++
+		}
+		i, err := strconv.Atoi(p.expr[start:p.pos])
+		if err != nil {
+			p.pos = start
+			return seg, p.errorf("expected index, '*', quoted key or filter")
+		}
+		seg.kind, seg.index = segmentIndex, i
+	}
+
+	if err := p.expect("]"); err != nil {
+		return seg, err
+	}
+	return seg, nil
+}
+
+func (p *pathParser) filter() (*pathFilter, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	if err := p.expect("@"); err != nil {
+		return nil, err
+	}
+
+	f := &pathFilter{}
+	for p.pos < len(p.expr) && p.expr[p.pos] == '.' {
+		p.pos++
+		name := p.ident()
+		if name == "" {
+			return nil, p.errorf("expected field name")
+		}
+		f.field = append(f.field, name)
+	}
+
+	p.skipSpaces()
+	for _, op := range []string{"==", "!=", "<=", ">=", "<", ">"} {
+		if strings.HasPrefix(p.expr[p.pos:], op) {
+			f.op = op
+			p.pos += len(op)
+			break
+		}
+	}
+	if f.op != "" {
+		p.skipSpaces()
+		v, err := p.literal()
+		if err != nil {
+			return nil, err
+		}
+		f.value = v
+	}
+
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (p *pathParser) literal() (any, error) {
+	if p.pos < len(p.expr) && (p.expr[p.pos] == '"' || p.expr[p.pos] == '\'') {
+		return p.quoted()
+	}
+	start := p.pos
+	for p.pos < len(p.expr) && !strings.ContainsRune(") \t", rune(p.expr[p.pos])) {
+		p.pos++
+	}
+	text := p.expr[start:p.pos]
+	switch text {
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	}
+	if i, err := strconv.Atoi(text); err == nil {
+		return i, nil
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil {
+		return f, nil
+	}
+	p.pos = start
+	return nil, p.errorf("expected string, number, true, false or null")
+}
+
+func (p *pathParser) quoted() (string, error) {
+	quote := p.expr[p.pos]
+	p.pos++
+	var sb strings.Builder
+	for p.pos < len(p.expr) {
+		c := p.expr[p.pos]
+		p.pos++
+		switch {
+		case c == '\\' && p.pos < len(p.expr):
+			sb.WriteByte(p.expr[p.pos])
+			p.pos++
+		case c == quote:
+			return sb.String(), nil
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
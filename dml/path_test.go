@@ -0,0 +1,118 @@
+package dml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const pathSrc = `list servers = [{"host": "a", "region": "eu", "port": 80}, {"host": "b", "region": "us", "port": 8080}, {"host": "c", "region": "eu", "port": 443}];
+map labels = {"app.kubernetes.io": "api", "tier": "web"};
+list ports = [80, 443, 8080];`
+
+func TestQuery(t *testing.T) {
+	cfg := mustParse(t, pathSrc)
+
+	tests := []struct {
+		expr string
+		want []any
+	}{
+		{"servers[1].host", []any{"b"}},
+		{"servers[-1].host", []any{"c"}},
+		{"servers[*].host", []any{"a", "b", "c"}},
+		{`servers[?(@.region=="eu")].host`, []any{"a", "c"}},
+		{`servers[?(@.port > 100)].host`, []any{"b", "c"}},
+		{`servers[?(@.region != 'eu')].port`, []any{8080}},
+		{`labels["app.kubernetes.io"]`, []any{"api"}},
+		{"labels.*", []any{"api", "web"}},
+		{"ports[?(@ >= 443)]", []any{443, 8080}},
+		{"servers[7].host", []any{}},
+		{"missing[*]", []any{}},
+	}
+	for _, tt := range tests {
+		got, err := cfg.Query(tt.expr)
+		if err != nil {
+			t.Errorf("Query(%q): %v", tt.expr, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Query(%q) = %v, want %v", tt.expr, got, tt.want)
+		}
+	}
+}
+
+func TestQuery_SyntaxErrors(t *testing.T) {
+	cfg := mustParse(t, pathSrc)
+	for _, expr := range []string{"", "servers[", "servers[x]", "servers[?(@.a == )]", "a..b", `labels["x]`, "servers[0]host"} {
+		if _, err := cfg.Query(expr); err == nil {
+			t.Errorf("Query(%q): expected syntax error", expr)
+		}
+	}
+}
+
+func TestGetSet_IndexedPaths(t *testing.T) {
+	cfg := mustParse(t, pathSrc)
+
+	if cfg.GetString("servers[2].host") != "c" || cfg.GetInt("ports[0]") != 80 {
+		t.Error("expected indexed Get to resolve list items")
+	}
+	if _, ok := cfg.Get("servers[*].host"); ok {
+		t.Error("Get must not resolve paths that can match several values")
+	}
+
+	cfg.Set("servers[0].host", "a2")
+	cfg.Set("servers[1].tls.enabled", true)
+	cfg.Set("ports[3]", 9090)
+	cfg.Set("ports[10]", 1)
+
+	if cfg.GetString("servers[0].host") != "a2" || !cfg.GetBool("servers[1].tls.enabled") {
+		t.Errorf("indexed Set failed: %v", cfg.GetList("servers"))
+	}
+	if !reflect.DeepEqual(cfg.GetList("ports"), []any{80, 443, 8080, 9090}) {
+		t.Errorf("expected append at len and out of range ignored, got %v", cfg.GetList("ports"))
+	}
+}
+
+func TestSetPath_ReportsFailures(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{"ports[10]", "index 10 is out of range for ports, which has 3 items"},
+		{"ports[-4]", "out of range"},
+		{"servers[5].host", "out of range"},
+		{"servers[*].host", "more than one value"},
+		{`servers[?(@.region=="eu")].port`, "more than one value"},
+		{"labels.*", "more than one value"},
+		{"labels.tier[0]", "labels.tier is a string, not a list"},
+		{"missing[0]", "missing does not exist"},
+		{"servers[", "invalid path"},
+	}
+	for _, tt := range tests {
+		cfg := mustParse(t, pathSrc)
+		before := cfg.Clone()
+
+		err := cfg.SetPath(tt.key, 1)
+		if err == nil {
+			t.Errorf("SetPath(%q): expected an error", tt.key)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("SetPath(%q): expected error containing %q, got %v", tt.key, tt.want, err)
+		}
+		if !reflect.DeepEqual(cfg.data, before.data) {
+			t.Errorf("SetPath(%q): config changed despite the error", tt.key)
+		}
+	}
+
+	cfg := mustParse(t, pathSrc)
+	if err := cfg.SetPath("servers[-1].tls.enabled", true); err != nil {
+		t.Fatalf("SetPath: %v", err)
+	}
+	if err := cfg.SetPath("ports[3]", 9090); err != nil {
+		t.Fatalf("SetPath append: %v", err)
+	}
+	if !cfg.GetBool("servers[2].tls.enabled") || cfg.GetInt("ports[3]") != 9090 {
+		t.Errorf("SetPath did not set the values: %v %v", cfg.GetList("servers"), cfg.GetList("ports"))
+	}
+}
@@ -0,0 +1,345 @@
+package dml
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"path/filepath"
+	"sort"
+)
+
+// Finding is a lint issue or parse error tied to a file, in the shape shared
+// by the machine-readable report formats. Line and column ranges are 1-based
+// with an exclusive end.
+type Finding struct {
+	File      string        `json:"file"`
+	Code      string        `json:"code"`
+	Level     string        `json:"level"`
+	Message   string        `json:"message"`
+	Help      string        `json:"help,omitempty"`
+	Line      int           `json:"line"`
+	Column    int           `json:"column"`
+	EndLine   int           `json:"endLine"`
+	EndColumn int           `json:"endColumn"`
+	Fix       *SuggestedFix `json:"fix,omitempty"`
+}
+
+// Finding converts the issue into a report entry for file. Help is the
+// description of the rule that raised it.
+func (it LintIssue) Finding(file string) Finding {
+	f := Finding{
+		File:      file,
+		Code:      it.Code,
+		Level:     it.Level,
+		Message:   it.Message,
+		Line:      it.Line,
+		Column:    it.Column,
+		EndLine:   it.EndLine,
+		EndColumn: it.EndColumn,
+		Fix:       it.Fix,
+	}
+	if rule := lintRule(it.Code); rule != nil {
+		f.Help = rule.Description()
+	}
+	return f.normalize()
+}
+
+// Finding converts the error into a report entry for file, with the error's
+// Code as the rule.
+func (e *DMLError) Finding(file string) Finding {
+	f := Finding{
+		File:    file,
+		Code:    e.Code,
+		Level:   LintLevelError,
+		Message: e.Message,
+		Help:    e.Type.String(),
+		Line:    e.Line,
+		Column:  e.Column,
+	}
+	if f.Code == "" {
+		f.Code = "PARSE_ERROR"
+	}
+	return f.normalize()
+}
+
+// ErrorFinding converts any error into a report entry for file, keeping the
+// position of a DMLError.
+func ErrorFinding(file string, err error) Finding {
+	var dmlErr *DMLError
+	if errors.As(err, &dmlErr) {
+		return dmlErr.Finding(file)
+	}
+	return Finding{File: file, Code: "PARSE_ERROR", Level: LintLevelError, Message: err.Error()}.normalize()
+}
+
+func (f Finding) normalize() Finding {
+	if f.Line < 1 {
+		f.Line, f.Column = 1, 1
+	}
+	if f.Column < 1 {
+		f.Column = 1
+	}
+	if f.EndLine < f.Line || (f.EndLine == f.Line && f.EndColumn < f.Column) {
+		f.EndLine, f.EndColumn = f.Line, f.Column
+	}
+	return f
+}
+
+func lintRule(code string) LintRule {
+	lintRulesMu.RLock()
+	defer lintRulesMu.RUnlock()
+	return lintRules[code]
+}
+
+// WriteJSONLines writes one JSON object per finding, one per line.
+func WriteJSONLines(w io.Writer, findings []Finding) error {
+	enc := json.NewEncoder(w)
+	for _, f := range findings {
+		if err := enc.Encode(f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type checkstyleReport struct {
+	XMLName xml.Name         `xml:"checkstyle"`
+	Version string           `xml:"version,attr"`
+	Files   []checkstyleFile `xml:"file"`
+}
+
+type checkstyleFile struct {
+	Name   string            `xml:"name,attr"`
+	Errors []checkstyleError `xml:"error"`
+}
+
+type checkstyleError struct {
+	Line     int    `xml:"line,attr"`
+	Column   int    `xml:"column,attr"`
+	Severity string `xml:"severity,attr"`
+	Message  string `xml:"message,attr"`
+	Source   string `xml:"source,attr"`
+}
+
+// WriteCheckstyle writes findings as Checkstyle XML, grouped by file in the
+// order files first appear.
+func WriteCheckstyle(w io.Writer, findings []Finding) error {
+	report := checkstyleReport{Version: "4.3"}
+	index := make(map[string]int)
+	for _, f := range findings {
+		i, ok := index[f.File]
+		if !ok {
+			i = len(report.Files)
+			index[f.File] = i
+			report.Files = append(report.Files, checkstyleFile{Name: f.File})
+		}
+		severity := f.Level
+		if severity != LintLevelError && severity != LintLevelWarning {
+			severity = "info"
+		}
+		report.Files[i].Errors = append(report.Files[i].Errors, checkstyleError{
+			Line:     f.Line,
+			Column:   f.Column,
+			Severity: severity,
+			Message:  f.Message,
+			Source:   "dml." + f.Code,
+		})
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+const (
+	sarifVersion = "2.1.0"
+	sarifSchema  = "https://json.schemastore.org/sarif-2.1.0.json"
+	sarifToolURI = "https://github.com/tree-software-company/dml-go"
+)
+
+type sarifLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifRule struct {
+	ID               string              `json:"id"`
+	ShortDescription *sarifMessage       `json:"shortDescription,omitempty"`
+	Help             *sarifMessage       `json:"help,omitempty"`
+	DefaultConfig    *sarifConfiguration `json:"defaultConfiguration,omitempty"`
+}
+
+type sarifConfiguration struct {
+	Level string `json:"level"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	RuleIndex int             `json:"ruleIndex"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+	Fixes     []sarifFix      `json:"fixes,omitempty"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifact `json:"artifactLocation"`
+	Region           sarifRegion   `json:"region"`
+}
+
+type sarifArtifact struct {
+	URI string `json:"uri"`
+}
+
+// sarifRegion is either a line/column range or, for fixes, a byte range.
+type sarifRegion struct {
+	StartLine   int  `json:"startLine,omitempty"`
+	StartColumn int  `json:"startColumn,omitempty"`
+	EndLine     int  `json:"endLine,omitempty"`
+	EndColumn   int  `json:"endColumn,omitempty"`
+	ByteOffset  *int `json:"byteOffset,omitempty"`
+	ByteLength  *int `json:"byteLength,omitempty"`
+}
+
+type sarifFix struct {
+	Description     sarifMessage          `json:"description"`
+	ArtifactChanges []sarifArtifactChange `json:"artifactChanges"`
+}
+
+type sarifArtifactChange struct {
+	ArtifactLocation sarifArtifact      `json:"artifactLocation"`
+	Replacements     []sarifReplacement `json:"replacements"`
+}
+
+type sarifReplacement struct {
+	DeletedRegion   sarifRegion   `json:"deletedRegion"`
+	InsertedContent *sarifMessage `json:"insertedContent,omitempty"`
+}
+
+// WriteSARIF writes findings as a SARIF 2.1.0 log with a single run. Every
+// rule that produced a finding is listed with its help text, and suggested
+// fixes are included as byte-range replacements.
+func WriteSARIF(w io.Writer, findings []Finding) error {
+	rules := sarifRules(findings)
+	ruleIndex := make(map[string]int)
+	for i, r := range rules {
+		ruleIndex[r.ID] = i
+	}
+
+	results := make([]sarifResult, 0, len(findings))
+	for _, f := range findings {
+		artifact := sarifArtifact{URI: filepath.ToSlash(f.File)}
+		res := sarifResult{
+			RuleID:    f.Code,
+			RuleIndex: ruleIndex[f.Code],
+			Level:     sarifLevel(f.Level),
+			Message:   sarifMessage{Text: f.Message},
+			Locations: []sarifLocation{{PhysicalLocation: sarifPhysicalLocation{
+				ArtifactLocation: artifact,
+				Region: sarifRegion{
+					StartLine:   f.Line,
+					StartColumn: f.Column,
+					EndLine:     f.EndLine,
+					EndColumn:   f.EndColumn,
+				},
+			}}},
+		}
+		if f.Fix != nil {
+			res.Fixes = []sarifFix{sarifFixFor(artifact, f.Fix)}
+		}
+		results = append(results, res)
+	}
+
+	log := sarifLog{
+		Version: sarifVersion,
+		Schema:  sarifSchema,
+		Runs: []sarifRun{{
+			Tool:    sarifTool{Driver: sarifDriver{Name: "dml", InformationURI: sarifToolURI, Rules: rules}},
+			Results: results,
+		}},
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(log)
+}
+
+func sarifRules(findings []Finding) []sarifRule {
+	help := make(map[string]string)
+	var ids []string
+	for _, f := range findings {
+		if _, seen := help[f.Code]; !seen {
+			ids = append(ids, f.Code)
+			help[f.Code] = f.Help
+		} else if help[f.Code] == "" {
+			help[f.Code] = f.Help
+		}
+	}
+	sort.Strings(ids)
+
+	rules := make([]sarifRule, 0, len(ids))
+	for _, id := range ids {
+		r := sarifRule{ID: id}
+		if help[id] != "" {
+			r.ShortDescription = &sarifMessage{Text: help[id]}
+			r.Help = &sarifMessage{Text: help[id]}
+		}
+		if rule := lintRule(id); rule != nil {
+			r.DefaultConfig = &sarifConfiguration{Level: sarifLevel(rule.DefaultLevel())}
+		}
+		rules = append(rules, r)
+	}
+	return rules
+}
+
+func sarifFixFor(artifact sarifArtifact, fix *SuggestedFix) sarifFix {
+	change := sarifArtifactChange{ArtifactLocation: artifact}
+	for _, e := range fix.Edits {
+		offset, length := e.Start, e.End-e.Start
+		rep := sarifReplacement{DeletedRegion: sarifRegion{ByteOffset: &offset, ByteLength: &length}}
+		if e.NewText != "" {
+			rep.InsertedContent = &sarifMessage{Text: e.NewText}
+		}
+		change.Replacements = append(change.Replacements, rep)
+	}
+	return sarifFix{Description: sarifMessage{Text: fix.Description}, ArtifactChanges: []sarifArtifactChange{change}}
+}
+
+func sarifLevel(level string) string {
+	switch level {
+	case LintLevelError, LintLevelWarning:
+		return level
+	}
+	return "note"
+}
@@ -0,0 +1,163 @@
+package dml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"strings"
+	"testing"
+)
+
+func reportFindings(t *testing.T) []Finding {
+	t.Helper()
+	issues, err := LintSource([]byte("map a = {\"x\": 1,};\nmap b = {};\n"))
+	if err != nil {
+		t.Fatalf("LintSource error: %v", err)
+	}
+	var findings []Finding
+	for _, it := range issues {
+		findings = append(findings, it.Finding("conf/app.dml"))
+	}
+	parseErr := New().Parse("int a = 1\n")
+	return append(findings, ErrorFinding("conf/bad.dml", parseErr))
+}
+
+func TestFinding_FromIssueAndError(t *testing.T) {
+	findings := reportFindings(t)
+	if len(findings) != 3 {
+		t.Fatalf("expected 3 findings, got %+v", findings)
+	}
+
+	comma := findings[0]
+	if comma.Code != "MAP_TRAILING_COMMA" || comma.Line != 1 || comma.Column != 16 || comma.EndColumn != 17 {
+		t.Errorf("unexpected trailing comma finding: %+v", comma)
+	}
+	if comma.Help == "" || comma.Fix == nil {
+		t.Errorf("expected help text and fix, got %+v", comma)
+	}
+
+	parse := findings[2]
+	if parse.Code != "MISSING_SEMICOLON" || parse.Level != LintLevelError || parse.Line != 1 {
+		t.Errorf("unexpected parse error finding: %+v", parse)
+	}
+	if parse.EndLine != parse.Line || parse.EndColumn != parse.Column {
+		t.Errorf("parse error range should collapse to its position: %+v", parse)
+	}
+}
+
+func TestWriteSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteSARIF(&buf, reportFindings(t)); err != nil {
+		t.Fatalf("WriteSARIF error: %v", err)
+	}
+
+	var log struct {
+		Version string `json:"version"`
+		Runs    []struct {
+			Tool struct {
+				Driver struct {
+					Name  string `json:"name"`
+					Rules []struct {
+						ID   string `json:"id"`
+						Help struct {
+							Text string `json:"text"`
+						} `json:"help"`
+					} `json:"rules"`
+				} `json:"driver"`
+			} `json:"tool"`
+			Results []struct {
+				RuleID    string `json:"ruleId"`
+				RuleIndex int    `json:"ruleIndex"`
+				Level     string `json:"level"`
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct {
+							URI string `json:"uri"`
+						} `json:"artifactLocation"`
+						Region struct {
+							StartLine, StartColumn, EndLine, EndColumn int
+						} `json:"region"`
+					} `json:"physicalLocation"`
+				} `json:"locations"`
+				Fixes []json.RawMessage `json:"fixes"`
+			} `json:"results"`
+		} `json:"runs"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &log); err != nil {
+		t.Fatalf("invalid SARIF JSON: %v\n%s", err, buf.String())
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || log.Runs[0].Tool.Driver.Name != "dml" {
+		t.Fatalf("unexpected SARIF header: %s", buf.String())
+	}
+	run := log.Runs[0]
+	if len(run.Results) != 3 || len(run.Tool.Driver.Rules) != 3 {
+		t.Fatalf("expected 3 results and 3 rules, got %d and %d", len(run.Results), len(run.Tool.Driver.Rules))
+	}
+	for _, res := range run.Results {
+		rule := run.Tool.Driver.Rules[res.RuleIndex]
+		if rule.ID != res.RuleID || rule.Help.Text == "" {
+			t.Errorf("result %s points at rule %+v", res.RuleID, rule)
+		}
+	}
+	first := run.Results[0]
+	region := first.Locations[0].PhysicalLocation.Region
+	if first.Locations[0].PhysicalLocation.ArtifactLocation.URI != "conf/app.dml" || region.StartLine != 1 || region.StartColumn != 16 || region.EndColumn != 17 {
+		t.Errorf("unexpected location: %+v", first.Locations[0])
+	}
+	if len(first.Fixes) != 1 || !strings.Contains(string(first.Fixes[0]), `"byteLength": 1`) {
+		t.Errorf("expected a byte-range fix, got %s", first.Fixes)
+	}
+	if run.Results[1].Level != "warning" {
+		t.Errorf("EMPTY_MAP should be a warning, got %s", run.Results[1].Level)
+	}
+}
+
+func TestWriteCheckstyle(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteCheckstyle(&buf, reportFindings(t)); err != nil {
+		t.Fatalf("WriteCheckstyle error: %v", err)
+	}
+
+	var report struct {
+		Files []struct {
+			Name   string `xml:"name,attr"`
+			Errors []struct {
+				Line     int    `xml:"line,attr"`
+				Severity string `xml:"severity,attr"`
+				Source   string `xml:"source,attr"`
+			} `xml:"error"`
+		} `xml:"file"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &report); err != nil {
+		t.Fatalf("invalid Checkstyle XML: %v\n%s", err, buf.String())
+	}
+	if len(report.Files) != 2 || report.Files[0].Name != "conf/app.dml" || len(report.Files[0].Errors) != 2 {
+		t.Fatalf("unexpected grouping: %+v", report)
+	}
+	if e := report.Files[1].Errors[0]; e.Source != "dml.MISSING_SEMICOLON" || e.Severity != "error" {
+		t.Errorf("unexpected parse error entry: %+v", e)
+	}
+}
+
+func TestWriteJSONLines(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteJSONLines(&buf, reportFindings(t)); err != nil {
+		t.Fatalf("WriteJSONLines error: %v", err)
+	}
+	scanner := bufio.NewScanner(&buf)
+	lines := 0
+	for scanner.Scan() {
+		var f Finding
+		if err := json.Unmarshal(scanner.Bytes(), &f); err != nil {
+			t.Fatalf("line %d is not JSON: %v", lines+1, err)
+		}
+		if f.File == "" || f.Code == "" || f.Line == 0 {
+			t.Errorf("incomplete record: %s", scanner.Text())
+		}
+		lines++
+	}
+	if lines != 3 {
+		t.Errorf("expected 3 lines, got %d", lines)
+	}
+}
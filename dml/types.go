@@ -0,0 +1,89 @@
+package dml
+
+import "time"
+
+func (c *Config) setType(key, typeName string) {
+	if c.types == nil {
+		c.types = make(map[string]string)
+	}
+	c.types[key] = typeName
+}
+
+// DeclaredType reports the DML type recorded for key, either from its
+// declaration in a parsed file or inferred when the value was loaded.
+func (c *Config) DeclaredType(key string) (string, bool) {
+	t, ok := c.types[key]
+	return t, ok
+}
+
+// dmlTypeOf returns the DML type keyword that naturally describes value.
+func dmlTypeOf(value any) string {
+	switch value.(type) {
+	case string:
+		return "string"
+	case int, int64:
+		return "int"
+	case float64:
+		return "float"
+	case bool:
+		return "bool"
+	case time.Duration:
+		return "duration"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return "string"
+}
+
+// canonicalType folds DML type aliases onto a single spelling.
+func canonicalType(typeName string) string {
+	switch typeName {
+	case "number":
+		return "int"
+	case "boolean":
+		return "bool"
+	}
+	return typeName
+}
+
+// holdsType reports whether val is of the DML type typeName, or is an
+// environment template that LoadWithEnv will turn into one.
+func holdsType(typeName string, val any) bool {
+	if canonicalType(typeName) == dmlTypeOf(val) {
+		return true
+	}
+	s, ok := val.(string)
+	if !ok {
+		return false
+	}
+	_, isTemplate := envTemplate(typeName, s)
+	return isTemplate
+}
+
+// syncTypes drops recorded types for keys that no longer exist and re-infers
+// those whose value no longer matches the recorded type.
+func (c *Config) syncTypes() {
+	for key, typeName := range c.types {
+		val, exists := c.Get(key)
+		if !exists {
+			delete(c.types, key)
+			continue
+		}
+		if !holdsType(typeName, val) {
+			c.types[key] = dmlTypeOf(val)
+		}
+	}
+}
+
+// inferTypes records a type for every top-level key that has none yet and
+// refreshes stale entries.
+func (c *Config) inferTypes() {
+	for k, v := range c.data {
+		if _, declared := c.types[k]; !declared {
+			c.setType(k, dmlTypeOf(v))
+		}
+	}
+	c.syncTypes()
+}